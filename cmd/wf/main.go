@@ -0,0 +1,45 @@
+// wf 命令行工具
+//
+//	wf encrypt [value]  使用 WEB_CONFIG_KEY 或 WEB_CONFIG_KEY_FILE 中的主密钥加密配置值，输出 ENC(...)，
+//	                    未指定 value 时从标准输入读取，避免明文留在 shell 历史中
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chuccp/go-web-frame/config"
+)
+
+const usage = `usage: wf encrypt [value]`
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 || args[0] != "encrypt" {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	var value string
+	if len(args) > 1 {
+		value = args[1]
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && len(line) == 0 {
+			fmt.Fprintln(os.Stderr, "read value:", err)
+			return 1
+		}
+		value = strings.TrimRight(line, "\r\n")
+	}
+	encrypted, err := config.Encrypt(value)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(encrypted)
+	return 0
+}
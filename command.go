@@ -0,0 +1,219 @@
+package wf
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"emperror.dev/errors"
+	config2 "github.com/chuccp/go-web-frame/config"
+	"github.com/chuccp/go-web-frame/core"
+	db2 "github.com/chuccp/go-web-frame/db"
+	"github.com/chuccp/go-web-frame/log"
+	"github.com/chuccp/go-web-frame/util"
+	"go.uber.org/zap"
+	"go.yaml.in/yaml/v3"
+)
+
+// Command 子命令，args 为命令名之后的参数，
+// 使用 LoadAutoConfig 加载配置时 --key=value 形式的参数同时会作为配置项覆盖
+type Command struct {
+	Name  string
+	Usage string
+	Run   func(w *WebFrame, args []string) error
+}
+
+// ErrUsage 命令或参数错误，Execute 返回前已输出用法
+var ErrUsage = errors.Sentinel("usage error")
+
+// AddCommand 注册自定义子命令，与内置命令同名时替换内置命令
+func (w *WebFrame) AddCommand(command ...*Command) {
+	w.commands = append(w.commands, command...)
+}
+
+// SetVersion 设置 version 命令输出的版本号，未设置时使用构建信息中的版本
+func (w *WebFrame) SetVersion(version string) {
+	w.version = version
+}
+
+// SetOutput 设置命令和用法的输出，默认为标准输出
+func (w *WebFrame) SetOutput(output io.Writer) {
+	w.output = output
+}
+
+// Execute 执行子命令，未指定 args 时使用 os.Args[1:]，没有子命令时执行 serve
+//
+//	serve            启动服务
+//	routes           输出所有路由
+//	config print     输出合并后的配置，敏感值已屏蔽
+//	config validate  校验配置
+//	migrate          创建或升级所有 model 的表
+//	version          输出版本
+func (w *WebFrame) Execute(args ...string) error {
+	if args == nil {
+		args = os.Args[1:]
+	}
+	name := "serve"
+	index := slices.IndexFunc(args, func(arg string) bool { return !strings.HasPrefix(arg, "-") })
+	if index >= 0 {
+		name = args[index]
+		args = slices.Delete(slices.Clone(args), index, index+1)
+	}
+	if name == "help" {
+		w.usage()
+		return nil
+	}
+	commands := w.allCommands()
+	i := slices.IndexFunc(commands, func(command *Command) bool { return command.Name == name })
+	if i < 0 {
+		fmt.Fprintf(w.output, "unknown command %q\n", name)
+		w.usage()
+		return ErrUsage
+	}
+	err := commands[i].Run(w, args)
+	if errors.Is(err, ErrUsage) {
+		w.usage()
+	}
+	return err
+}
+
+// allCommands 内置命令在前，自定义命令替换同名的内置命令
+func (w *WebFrame) allCommands() []*Command {
+	commands := defaultCommands()
+	for _, command := range w.commands {
+		i := slices.IndexFunc(commands, func(c *Command) bool { return c.Name == command.Name })
+		if i >= 0 {
+			commands[i] = command
+		} else {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+func (w *WebFrame) usage() {
+	fmt.Fprintln(w.output, "usage: <command> [--key=value ...]")
+	fmt.Fprintln(w.output)
+	writer := tabwriter.NewWriter(w.output, 0, 0, 2, ' ', 0)
+	for _, command := range w.allCommands() {
+		fmt.Fprintf(writer, "  %s\t%s\n", command.Name, command.Usage)
+	}
+	writer.Flush()
+}
+
+func defaultCommands() []*Command {
+	return []*Command{
+		{Name: "serve", Usage: "start the server", Run: func(w *WebFrame, args []string) error {
+			return w.Start()
+		}},
+		{Name: "routes", Usage: "print all routes", Run: (*WebFrame).printRoutes},
+		{Name: "config", Usage: "print | validate the config", Run: (*WebFrame).configCommand},
+		{Name: "migrate", Usage: "create or upgrade the tables of all models", Run: func(w *WebFrame, args []string) error {
+			return w.Migrate()
+		}},
+		{Name: "version", Usage: "print the version", Run: (*WebFrame).printVersion},
+	}
+}
+
+// printRoutes 初始化所有 rest 但不绑定端口，输出后关闭
+func (w *WebFrame) printRoutes(args []string) error {
+	err := w.init()
+	if err != nil {
+		return errors.Combine(err, w.Close())
+	}
+	writer := tabwriter.NewWriter(w.output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PORT\tMETHOD\tPATH\tHANDLER\tAUTH")
+	for _, route := range w.context.Routes() {
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%t\n", route.Port, route.Method, route.Path, route.Handler, route.Auth)
+	}
+	return errors.Combine(writer.Flush(), w.Close())
+}
+
+func (w *WebFrame) configCommand(args []string) error {
+	flagSet := flag.NewFlagSet("config", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	err := flagSet.Parse(args)
+	if err != nil || flagSet.NArg() == 0 {
+		return ErrUsage
+	}
+	switch flagSet.Arg(0) {
+	case "print":
+		data, err := yaml.Marshal(config2.MaskSecrets(w.config.AllSettings()))
+		if err != nil {
+			return errors.WithStackIf(err)
+		}
+		_, err = w.output.Write(data)
+		return errors.WithStackIf(err)
+	case "validate":
+		err := w.validateConfig()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w.output, "config is valid")
+		return nil
+	default:
+		return ErrUsage
+	}
+}
+
+// Migrate 初始化到 model 后为每个 model 创建或升级表，实现了 core.IMigrate 时调用 Migrate，否则只调用 CreateTable 创建不存在的表
+func (w *WebFrame) Migrate() error {
+	w.lock.Lock()
+	_, err := w.initModels()
+	w.lock.Unlock()
+	if err == nil {
+		err = w.migrate()
+	}
+	return errors.Combine(err, w.Close())
+}
+
+func (w *WebFrame) migrate() error {
+	modelGroups := w.modelGroup
+	if w.config.HasKey(db2.ConfigKey) {
+		modelGroups = append([]core.IModelGroup{w.defaultModelGroup}, modelGroups...)
+	} else if len(w.defaultModelGroup.GetModel()) > 0 {
+		return errors.Errorf("%s is not configured", db2.ConfigKey)
+	}
+	for _, modelGroup := range modelGroups {
+		for _, model := range modelGroup.GetModel() {
+			var err error
+			if migrate, ok := model.(core.IMigrate); ok {
+				err = migrate.Migrate()
+			} else {
+				err = model.CreateTable()
+			}
+			if err != nil {
+				return errors.WrapIff(err, "migrate %s", util.GetStructFullName(model))
+			}
+			log.Info("Migrated", zap.String("group", modelGroup.Name()), zap.String("table", model.GetTableName()))
+		}
+	}
+	return nil
+}
+
+func (w *WebFrame) printVersion(args []string) error {
+	version := w.version
+	info, ok := debug.ReadBuildInfo()
+	if len(version) == 0 && ok {
+		version = info.Main.Version
+	}
+	if len(version) == 0 {
+		version = "(devel)"
+	}
+	fmt.Fprintln(w.output, version)
+	if ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" || setting.Key == "vcs.time" {
+				fmt.Fprintf(w.output, "%s: %s\n", setting.Key, setting.Value)
+			}
+		}
+	}
+	fmt.Fprintf(w.output, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
+}
@@ -0,0 +1,105 @@
+package wf
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	config2 "github.com/chuccp/go-web-frame/config"
+	"github.com/chuccp/go-web-frame/core"
+	db2 "github.com/chuccp/go-web-frame/db"
+	"github.com/chuccp/go-web-frame/model"
+	"github.com/chuccp/go-web-frame/web"
+)
+
+type commandEntry struct {
+	Id   uint   `gorm:"primaryKey;autoIncrement"`
+	Name string `gorm:"size:64"`
+}
+
+type commandModel struct {
+	*model.Model[*commandEntry]
+}
+
+func (m *commandModel) Init(db *db2.DB, c *core.Context) error {
+	m.Model = model.NewModel[*commandEntry](db, "t_command")
+	return nil
+}
+
+func (m *commandModel) ReNew(db *db2.DB, c *core.Context) core.IModel {
+	return &commandModel{Model: model.NewModel[*commandEntry](db, "t_command")}
+}
+
+type commandRest struct {
+}
+
+func (r *commandRest) Init(ctx *core.Context) error {
+	ctx.GetAuth("/user", r.user)
+	return nil
+}
+
+func (r *commandRest) user(req *web.Request) (any, error) {
+	return nil, nil
+}
+
+func newCommandFrame(t *testing.T) (*WebFrame, *bytes.Buffer) {
+	config := config2.NewConfig()
+	config.Put("web.server.port", 18080)
+	config.Put("web.db.type", db2.SQLITE)
+	config.Put("web.db.filePath", filepath.Join(t.TempDir(), "command.db"))
+	config.Put("web.redis.password", "123456")
+	webFrame := New(config)
+	webFrame.AddRest(&commandRest{})
+	var output bytes.Buffer
+	webFrame.SetOutput(&output)
+	return webFrame, &output
+}
+
+func TestExecute(t *testing.T) {
+	webFrame, output := newCommandFrame(t)
+	err := webFrame.Execute("routes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.String(), "18080  GET     /user") || !strings.Contains(output.String(), "commandRest") {
+		t.Fatalf("unexpected routes:\n%s", output)
+	}
+
+	webFrame, output = newCommandFrame(t)
+	err = webFrame.Execute("config", "print")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(output.String(), "123456") || !strings.Contains(output.String(), config2.Mask) {
+		t.Fatalf("password should be masked:\n%s", output)
+	}
+
+	webFrame, _ = newCommandFrame(t)
+	commandModel := &commandModel{}
+	webFrame.AddModel(commandModel)
+	err = webFrame.Execute("migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !commandModel.IsExist() {
+		t.Fatal("table should be created")
+	}
+
+	webFrame, output = newCommandFrame(t)
+	called := false
+	webFrame.AddCommand(&Command{Name: "hello", Run: func(w *WebFrame, args []string) error {
+		called = len(args) == 1 && args[0] == "world"
+		return nil
+	}})
+	err = webFrame.Execute("hello", "world")
+	if err != nil || !called {
+		t.Fatalf("custom command not called: %v", err)
+	}
+	if err := webFrame.Execute("unknown"); err != ErrUsage {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+	if !strings.Contains(output.String(), `unknown command "unknown"`) || !strings.Contains(output.String(), "hello") {
+		t.Fatalf("usage should be written to the output:\n%s", output)
+	}
+}
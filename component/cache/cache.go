@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"sync/atomic"
 	"time"
 
 	"emperror.dev/errors"
@@ -10,12 +11,33 @@ import (
 )
 
 type Config struct {
-	MaxSize int // 最大缓存数量
-	Expiry  int // 缓存过期时间 单位秒
+	MaxSize int `validate:"required,min=1"` // 最大缓存数量
+	Expiry  int `validate:"required,min=1"` // 缓存过期时间 单位秒
+}
+
+func (c *Config) Key() string {
+	return "cache"
+}
+
+func init() {
+	config2.Register((&Config{}).Key(), &Config{})
+}
+
+func loadConfig(config config2.IConfig) (*Config, error) {
+	lConfig := &Config{
+		MaxSize: 1000_000,
+		Expiry:  3600,
+	}
+	err := config.Unmarshal(lConfig.Key(), lConfig)
+	if err != nil {
+		return nil, err
+	}
+	return lConfig, nil
 }
 
 type Cache struct {
-	cache *otter.Cache[string, any]
+	cache  *otter.Cache[string, any]
+	config atomic.Pointer[Config]
 }
 
 // Get 获取缓存值（如果不存在，返回 nil 和 false）
@@ -38,15 +60,18 @@ func (c *Cache) Stats() stats.Stats {
 }
 
 func (c *Cache) Init(config config2.IConfig) error {
-	lConfig := &Config{
-		MaxSize: 1000_000,
-		Expiry:  3600,
+	lConfig, err := loadConfig(config)
+	if err != nil {
+		return err
 	}
+	c.config.Store(lConfig)
 	counter := stats.NewCounter()
 	cache, err := otter.New(&otter.Options[string, any]{
-		MaximumSize:      lConfig.MaxSize,
-		ExpiryCalculator: otter.ExpiryAccessing[string, any](time.Duration(lConfig.Expiry) * time.Second),
-		StatsRecorder:    counter,
+		MaximumSize: lConfig.MaxSize,
+		ExpiryCalculator: otter.ExpiryAccessingFunc[string, any](func(entry otter.Entry[string, any]) time.Duration {
+			return time.Duration(c.config.Load().Expiry) * time.Second
+		}),
+		StatsRecorder: counter,
 	})
 	if err != nil {
 		return errors.WithStackIf(err)
@@ -55,6 +80,23 @@ func (c *Cache) Init(config config2.IConfig) error {
 	return nil
 }
 
+// ValidateConfig 启动前和配置重新加载时校验 cache 配置
+func (c *Cache) ValidateConfig(config config2.IConfig) error {
+	_, err := loadConfig(config)
+	return err
+}
+
+// Reload 配置变化后调整缓存容量，新的过期时间在缓存项下次访问时生效
+func (c *Cache) Reload(config config2.IConfig) error {
+	lConfig, err := loadConfig(config)
+	if err != nil {
+		return err
+	}
+	c.config.Store(lConfig)
+	c.cache.SetMaximum(uint64(lConfig.MaxSize))
+	return nil
+}
+
 func (c *Cache) Destroy() error {
 	if stopped := c.cache.StopAllGoroutines(); stopped {
 	}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"math"
 
+	"emperror.dev/errors"
 	config2 "github.com/chuccp/go-web-frame/config"
 	"github.com/chuccp/go-web-frame/log"
 	"github.com/chuccp/go-web-frame/util"
@@ -37,25 +38,39 @@ type Data struct {
 	CaptchaCode string `json:"captchaCode"`
 }
 type Config struct {
-	CodeKey string
-	CodeIv  string
+	CodeKey string `validate:"required,min=32"`
+	CodeIv  string `validate:"required,min=16"`
 }
 
 func (c *Config) Key() string {
 	return "captcha"
 }
 
+func init() {
+	config2.Register((&Config{}).Key(), &Config{})
+}
+
 type SlideCaptcha struct {
 }
 
+// ValidateConfig 启动前校验 captcha 配置，codeKey 至少 32 个字符，codeIv 至少 16 个字符
+func (c *Captcha) ValidateConfig(config config2.IConfig) error {
+	var cfg Config
+	return config.Unmarshal(cfg.Key(), &cfg)
+}
+
 func (c *Captcha) Init(config config2.IConfig) error {
 	var cfg Config
 	err := config.Unmarshal(cfg.Key(), &cfg)
 	if err != nil {
 		return err
 	}
-	c.key = util.SubStringAndPadSpace(cfg.CodeKey, 32)
-	c.iv = util.SubStringAndPadSpace(cfg.CodeIv, 16)
+	// 配置可能未经过 Unmarshal 的校验，截取前检查长度
+	if len(cfg.CodeKey) < 32 || len(cfg.CodeIv) < 16 {
+		return errors.New("captcha codeKey must be at least 32 characters and codeIv at least 16 characters")
+	}
+	c.key = cfg.CodeKey[:32]
+	c.iv = cfg.CodeIv[:16]
 	builder := slide.NewBuilder()
 	images, err := imagesv2.GetImages()
 	if err != nil {
@@ -2,6 +2,7 @@ package ratelimit
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"emperror.dev/errors"
@@ -12,10 +13,36 @@ import (
 )
 
 type Config struct {
-	Limit   int // 每秒限制
-	Burst   int // 最大令牌数
-	MaxSize int // 最大缓存数量
-	Expiry  int // 缓存过期时间 单位秒
+	Limit   int `validate:"required,min=1"` // 每秒限制
+	Burst   int `validate:"required,min=1"` // 最大令牌数
+	MaxSize int `validate:"required,min=1"` // 最大缓存数量
+	Expiry  int `validate:"required,min=1"` // 缓存过期时间 单位秒
+}
+
+func (c *Config) Key() string {
+	return "rate_limit"
+}
+
+func init() {
+	config2.Register((&Config{}).Key(), &Config{})
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Limit:   600,
+		Burst:   3,
+		MaxSize: 1000_000,
+		Expiry:  3600,
+	}
+}
+
+func loadConfig(config config2.IConfig) (*Config, error) {
+	lConfig := defaultConfig()
+	err := config.Unmarshal(lConfig.Key(), lConfig)
+	if err != nil {
+		return nil, err
+	}
+	return lConfig, nil
 }
 
 type RateLimit struct {
@@ -23,7 +50,7 @@ type RateLimit struct {
 	limiterLoader otter.Loader[string, *rate.Limiter]
 	ctx           context.Context
 	cancelFunc    context.CancelFunc
-	config        *Config
+	config        atomic.Pointer[Config]
 }
 
 // Allow 瞬间检查是否允许（不阻塞，直接返回 false 拒绝）
@@ -53,32 +80,29 @@ func (r *RateLimit) Wait(key string) error {
 func (r *RateLimit) _limiterLoader(burst int) otter.Loader[string, *rate.Limiter] {
 	return otter.LoaderFunc[string, *rate.Limiter](func(ctx context.Context, key string) (*rate.Limiter, error) {
 		// 每 15 分钟允许 3 次请求 → 每 5 分钟填充 1 个令牌，burst = 3
-		return rate.NewLimiter(rate.Every(time.Duration(r.config.Limit)*time.Second), burst), nil
+		return rate.NewLimiter(rate.Every(time.Duration(r.config.Load().Limit)*time.Second), burst), nil
 	})
 }
 func (r *RateLimit) Init(config config2.IConfig) error {
-	lConfig := &Config{
-		Limit:   600,
-		Burst:   3,
-		MaxSize: 1000_000,
-		Expiry:  3600,
-	}
-
 	r.ctx, r.cancelFunc = context.WithCancel(context.Background())
-	err := config.Unmarshal("rate_limit", lConfig)
+	lConfig, err := loadConfig(config)
 	if err != nil {
-		return errors.WithStackIf(err)
+		return err
 	}
-	r.config = lConfig
+	r.config.Store(lConfig)
 	r.limiterLoader = otter.LoaderFunc[string, *rate.Limiter](func(ctx context.Context, key string) (*rate.Limiter, error) {
 		// 每 15 分钟允许 3 次请求 → 每 5 分钟填充 1 个令牌，burst = 3
+		lConfig := r.config.Load()
 		return rate.NewLimiter(rate.Every(time.Duration(lConfig.Limit)*time.Second), lConfig.Burst), nil
 	})
 	counter := stats.NewCounter()
 	cache, err := otter.New[string, *rate.Limiter](&otter.Options[string, *rate.Limiter]{
-		MaximumSize:      lConfig.MaxSize,
-		ExpiryCalculator: otter.ExpiryAccessing[string, *rate.Limiter](time.Duration(lConfig.Expiry) * time.Second), // 最后访问后 1 小时过期
-		StatsRecorder:    counter,
+		MaximumSize: lConfig.MaxSize,
+		// 最后访问后 1 小时过期
+		ExpiryCalculator: otter.ExpiryAccessingFunc[string, *rate.Limiter](func(entry otter.Entry[string, *rate.Limiter]) time.Duration {
+			return time.Duration(r.config.Load().Expiry) * time.Second
+		}),
+		StatsRecorder: counter,
 	})
 	if err != nil {
 		return errors.WithStackIf(err)
@@ -87,6 +111,27 @@ func (r *RateLimit) Init(config config2.IConfig) error {
 	return nil
 }
 
+// ValidateConfig 启动前和配置重新加载时校验 rate_limit 配置
+func (r *RateLimit) ValidateConfig(config config2.IConfig) error {
+	_, err := loadConfig(config)
+	return err
+}
+
+// Reload 配置变化后使用新的限流参数，已有的限流器按新参数重建
+func (r *RateLimit) Reload(config config2.IConfig) error {
+	lConfig, err := loadConfig(config)
+	if err != nil {
+		return err
+	}
+	if *lConfig == *r.config.Load() {
+		return nil
+	}
+	r.config.Store(lConfig)
+	r.cache.SetMaximum(uint64(lConfig.MaxSize))
+	r.cache.InvalidateAll()
+	return nil
+}
+
 func (r *RateLimit) Destroy() error {
 	r.cancelFunc()
 	r.cache.CleanUp()
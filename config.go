@@ -1,10 +1,33 @@
 package wf
 
-import config2 "github.com/chuccp/go-web-frame/config"
+import (
+	config2 "github.com/chuccp/go-web-frame/config"
+	"github.com/chuccp/go-web-frame/core"
+	"github.com/chuccp/go-web-frame/log"
+	"github.com/chuccp/go-web-frame/web"
+)
+
+// 框架自身的配置项不在配置文件中时也可以通过环境变量设置
+func init() {
+	config2.Register((&log.Config{}).Key(), &log.Config{})
+	config2.Register(web.ServerConfigKey, &web.ServerConfig{})
+	config2.Register((&core.LifecycleConfig{}).Key(), &core.LifecycleConfig{})
+	config2.Register((&core.HealthConfig{}).Key(), &core.HealthConfig{})
+	config2.Register((&core.ScheduleConfig{}).Key(), &core.ScheduleConfig{})
+	config2.Register((&DaemonConfig{}).Key(), &DaemonConfig{})
+}
 
 func LoadConfig(paths ...string) (*config2.Config, error) {
 	return config2.LoadConfig(paths...)
 }
+
+// LoadAutoConfig 自动查找 application 配置文件，并合并 profile、环境变量和命令行参数，优先级见 config.LoadAutoConfigE，
+// 加载失败时记录日志并返回空的配置
 func LoadAutoConfig() *config2.Config {
 	return config2.LoadAutoConfig()
 }
+
+// LoadAutoConfigE 与 LoadAutoConfig 相同，加载失败时返回错误
+func LoadAutoConfigE() (*config2.Config, error) {
+	return config2.LoadAutoConfigE()
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/log"
+	"github.com/chuccp/go-web-frame/util"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// ConfigName 自动查找的配置文件名
+	ConfigName = "application"
+	// ProfileKey 激活的 profile，多个用逗号分隔，可通过环境变量 WEB_PROFILE 或 --web.profile=prod 指定
+	ProfileKey = "web.profile"
+)
+
+// ConfigDirs 自动查找配置文件的目录，后面的目录优先级更高
+var ConfigDirs = []string{".", "config"}
+
+// ConfigExts 自动查找的配置文件扩展名，同一目录下按顺序合并
+var ConfigExts = []string{"yml", "yaml", "json", "toml", "ini"}
+
+type registeredConfig struct {
+	key string
+	t   reflect.Type
+}
+
+var registered = struct {
+	lock    sync.Mutex
+	configs []registeredConfig
+}{}
+
+// Register 注册 key 对应的配置结构体，其中的配置项不在配置文件中时也可以通过环境变量设置，
+// v 不是结构体时 key 本身为一个配置项；框架和内置组件的配置已在各自的包中注册
+func Register(key string, v any) {
+	registered.lock.Lock()
+	defer registered.lock.Unlock()
+	registered.configs = append(registered.configs, registeredConfig{key: key, t: reflect.TypeOf(v)})
+}
+
+// registeredKeys 返回所有注册的配置项
+func registeredKeys() []string {
+	registered.lock.Lock()
+	defer registered.lock.Unlock()
+	keys := make([]string, 0)
+	for _, c := range registered.configs {
+		keys = append(keys, configKeys(c.key, c.t)...)
+	}
+	return keys
+}
+
+// configKeys 返回 t 中所有配置项的完整路径，嵌套的结构体展开，map 无法用一个值设置，忽略
+func configKeys(key string, t reflect.Type) []string {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == nil:
+		return []string{strings.ToLower(key)}
+	case t.Kind() == reflect.Map:
+		return nil
+	case t.Kind() == reflect.Struct:
+		keys := make([]string, 0)
+		for _, f := range structFields(t) {
+			keys = append(keys, configKeys(joinKey(key, f.name), f.field.Type)...)
+		}
+		return keys
+	}
+	return []string{strings.ToLower(key)}
+}
+
+// LoadAutoConfig 与 LoadAutoConfigE 相同，加载失败时记录日志并返回空的配置
+func LoadAutoConfig() *Config {
+	c, err := LoadAutoConfigE()
+	if err != nil {
+		log.Error("Failed to load the configuration", zap.Error(err))
+		return NewConfig()
+	}
+	return c
+}
+
+// LoadAutoConfigE 自动查找并加载配置，优先级从低到高：
+//
+//  1. 工作目录及 config/ 目录下的 application.{yml,yaml,json,toml,ini}，config/ 优先
+//  2. profile 配置 application-{profile}.{ext}，多个 profile 时后面的优先
+//  3. 环境变量，配置项 web.server.port 对应 WEB_SERVER_PORT，
+//     只覆盖配置文件中已有的配置项和通过 Register 注册的配置项
+//  4. 命令行参数 --web.server.port=8080
+//
+// 找到的配置文件会被监听，修改后重新加载
+func LoadAutoConfigE() (*Config, error) {
+	args := os.Args[1:]
+	files := discoverFiles(activeProfiles(args))
+	load := withSecrets(func() (*viper.Viper, error) {
+		_viper_, err := readFiles(files...)
+		if err != nil {
+			return nil, err
+		}
+		err = mergeEnv(_viper_, os.Environ())
+		if err != nil {
+			return nil, err
+		}
+		err = mergeArgs(_viper_, args)
+		if err != nil {
+			return nil, err
+		}
+		return _viper_, nil
+	})
+	_viper_, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		log.Info("No configuration file found", zap.Strings("dirs", ConfigDirs))
+	}
+	for _, file := range files {
+		log.Info("Load the configuration file", zap.String("path", file))
+	}
+	c := newConfig(_viper_, load, files...)
+	c.watch()
+	return c, nil
+}
+
+// activeProfiles 命令行参数优先于环境变量
+func activeProfiles(args []string) []string {
+	value := os.Getenv(envName(ProfileKey))
+	for key, v := range parseArgs(args) {
+		if key == ProfileKey {
+			value = v
+		}
+	}
+	profiles := make([]string, 0)
+	for _, profile := range strings.Split(value, ",") {
+		profile = strings.TrimSpace(profile)
+		if len(profile) > 0 {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+func discoverFiles(profiles []string) []string {
+	names := []string{ConfigName}
+	for _, profile := range profiles {
+		names = append(names, ConfigName+"-"+profile)
+	}
+	files := make([]string, 0)
+	for _, name := range names {
+		for _, dir := range ConfigDirs {
+			for _, ext := range ConfigExts {
+				path, err := filepath.Abs(filepath.Join(dir, name+"."+ext))
+				if err != nil {
+					continue
+				}
+				if util.ExistsFile(path) {
+					files = append(files, path)
+				}
+			}
+		}
+	}
+	return files
+}
+
+// envName web.server.port 转换为 WEB_SERVER_PORT
+func envName(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// mergeEnv 只覆盖已有的配置项和注册的配置项，不会新增其他配置项，也不会把配置块替换为单个值
+func mergeEnv(v *viper.Viper, environ []string) error {
+	env := make(map[string]string)
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if ok {
+			env[name] = value
+		}
+	}
+	values := make(map[string]string)
+	for _, key := range append(v.AllKeys(), registeredKeys()...) {
+		key = strings.ToLower(key)
+		if value, ok := env[envName(key)]; ok {
+			values[key] = value
+		}
+	}
+	return mergeValues(v, values)
+}
+
+// parseArgs 只解析 --key=value 形式的参数，其他参数忽略
+func parseArgs(args []string) map[string]string {
+	values := make(map[string]string)
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if ok && len(key) > 0 {
+			values[strings.ToLower(key)] = value
+		}
+	}
+	return values
+}
+
+func mergeArgs(v *viper.Viper, args []string) error {
+	return mergeValues(v, parseArgs(args))
+}
+
+// mergeValues 按层级合并到配置中，不会覆盖同级的其他配置项
+func mergeValues(v *viper.Viper, values map[string]string) error {
+	for key, value := range values {
+		parts := strings.Split(key, ".")
+		var m any = value
+		for i := len(parts) - 1; i >= 0; i-- {
+			m = map[string]any{parts[i]: m}
+		}
+		err := v.MergeConfigMap(m.(map[string]any))
+		if err != nil {
+			return errors.WrapIff(err, "merge config %s", key)
+		}
+	}
+	return nil
+}
@@ -2,10 +2,12 @@ package config
 
 import (
 	"path/filepath"
+	"sync"
 
 	"emperror.dev/errors"
 	"github.com/chuccp/go-web-frame/log"
 	"github.com/chuccp/go-web-frame/util"
+	"github.com/fsnotify/fsnotify"
 	"github.com/go-viper/encoding/ini"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -21,38 +23,94 @@ type IConfig interface {
 	GetBoolOrDefault(key string, defaultValue bool) bool
 	Unmarshal(key string, v any) error
 	ReplaceKey(key string, newKey string)
+	Subscribe(key string, fn SubscribeFunc)
+	AddValidator(fn ValidateFunc)
+	AllSettings() map[string]any
 }
 
 type Config struct {
-	v *viper.Viper
+	v           *viper.Viper
+	lock        *sync.RWMutex
+	load        func() (*viper.Viper, error)
+	files       []string
+	overrides   map[string]any
+	subscribers []*subscriber
+	validators  []ValidateFunc
+	watcher     *fsnotify.Watcher
 }
 
+func newConfig(v *viper.Viper, load func() (*viper.Viper, error), files ...string) *Config {
+	return &Config{
+		v:         v,
+		lock:      new(sync.RWMutex),
+		load:      load,
+		files:     files,
+		overrides: make(map[string]any),
+	}
+}
+
+func (c *Config) viper() *viper.Viper {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.v
+}
+
+// GetString ENC(...) 和 FILE(/path) 的值返回解析后的内容，无法解析时记录日志并返回空字符串
 func (c *Config) GetString(key string) string {
-	return c.v.GetString(key)
+	value, _, err := resolveSecret(c.viper().GetString(key))
+	if err != nil {
+		log.Error("resolve config", zap.String("key", key), zap.Error(err))
+		return ""
+	}
+	return value
 }
 func (c *Config) Put(key string, value any) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.overrides[key] = value
 	c.v.Set(key, value)
 }
 func (c *Config) GetStringOrDefault(key string, defaultValue string) string {
-	v := c.v.GetString(key)
+	v := c.GetString(key)
 	if util.IsBlank(v) {
 		return defaultValue
 	}
 	return v
 }
 func (c *Config) HasKey(key string) bool {
-	return c.v.IsSet(key)
+	return c.viper().IsSet(key)
 }
+
+// Unmarshal 解析配置后按 validate 标签和 Validator 校验，
+// 所有错误合并为一个 *ValidationError，ENC(...) 和 FILE(/path) 的值解析后再赋值
 func (c *Config) Unmarshal(key string, v any) error {
-	return errors.WithStackIf(c.v.UnmarshalKey(key, v))
+	_viper_ := c.viper()
+	settings, changed, err := resolveSecrets(key, _viper_.Get(key))
+	if err != nil {
+		return err
+	}
+	if changed {
+		_viper_ = viper.New()
+		_viper_.Set(key, settings)
+	}
+	err = _viper_.UnmarshalKey(key, v)
+	if err != nil {
+		return errors.WithStackIf(err)
+	}
+	return validate(key, v)
+}
+
+// AllSettings 合并后的全部配置，ENC(...) 和 FILE(/path) 的值不解析
+func (c *Config) AllSettings() map[string]any {
+	return c.viper().AllSettings()
 }
 
 func (c *Config) GetInt(key string) int {
-	return c.v.GetInt(key)
+	return c.viper().GetInt(key)
 }
 
 func (c *Config) GetIntOrDefault(key string, defaultValue int) int {
-	v := c.v.GetInt(key)
+	v := c.viper().GetInt(key)
 	if v == 0 {
 		return defaultValue
 	}
@@ -62,10 +120,13 @@ func (c *Config) GetBoolOrDefault(key string, defaultValue bool) bool {
 	if util.IsBlank(key) {
 		return defaultValue
 	}
-	return c.v.GetBool(key)
+	return c.viper().GetBool(key)
 }
 func (c *Config) ReplaceKey(key string, newKey string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	if c.v.IsSet(key) {
+		c.overrides[newKey] = c.v.Get(key)
 		c.v.Set(newKey, c.v.Get(key))
 	}
 }
@@ -76,56 +137,88 @@ type SingleFileConfig struct {
 }
 
 func (c *SingleFileConfig) WriteConfig() error {
-	return c.v.WriteConfig()
+	return c.viper().WriteConfig()
 }
 func LoadSingleFileConfig(path string) (*SingleFileConfig, error) {
-	registry := viper.NewCodecRegistry()
-	er := registry.RegisterCodec("ini", ini.Codec{})
-	if er != nil {
-		return nil, errors.WithStackIf(er)
-	}
 	absPath, err := filepath.Abs(path)
 	if err != nil {
-		return nil, errors.WithStackIf(er)
+		return nil, errors.WithStackIf(err)
 	}
 	log.Info("Load the configuration file", zap.String("path", absPath))
 	err = util.CreateFileIfNoExists(absPath)
 	if err != nil {
 		return nil, err
 	}
-	_viper_ := viper.NewWithOptions(viper.WithCodecRegistry(registry))
-	_viper_.SetConfigFile(absPath)
-	err = _viper_.ReadInConfig()
+	load := withSecrets(func() (*viper.Viper, error) {
+		return readSingleFile(absPath)
+	})
+	_viper_, err := load()
 	if err != nil {
-		return nil, errors.WithStackIf(err)
+		return nil, err
 	}
-	return &SingleFileConfig{Config: &Config{v: _viper_}, path: absPath}, nil
+	c := &SingleFileConfig{Config: newConfig(_viper_, load, absPath), path: absPath}
+	c.watch()
+	return c, nil
 }
 
-func NewConfig() *Config {
-	return &Config{v: viper.New()}
-}
-func LoadConfig(paths ...string) (*Config, error) {
+func newCodecRegistry() (*viper.DefaultCodecRegistry, error) {
 	registry := viper.NewCodecRegistry()
 	err := registry.RegisterCodec("ini", ini.Codec{})
 	if err != nil {
 		return nil, errors.WithStackIf(err)
 	}
+	return registry, nil
+}
+
+func readSingleFile(path string) (*viper.Viper, error) {
+	registry, err := newCodecRegistry()
+	if err != nil {
+		return nil, err
+	}
+	_viper_ := viper.NewWithOptions(viper.WithCodecRegistry(registry))
+	_viper_.SetConfigFile(path)
+	err = _viper_.ReadInConfig()
+	if err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+	return _viper_, nil
+}
+
+func readFiles(paths ...string) (*viper.Viper, error) {
 	_viper_ := viper.New()
 	for _, path := range paths {
-		viper2 := viper.NewWithOptions(viper.WithCodecRegistry(registry))
-		viper2.SetConfigFile(path)
-		err := viper2.ReadInConfig()
+		viper2, err := readSingleFile(path)
 		if err != nil {
-			return nil, errors.WithStackIf(err)
+			return nil, err
 		}
 		err = _viper_.MergeConfigMap(viper2.AllSettings())
 		if err != nil {
 			return nil, errors.WithStackIf(err)
 		}
 	}
-	return &Config{v: _viper_}, nil
+	return _viper_, nil
 }
-func LoadAutoConfig() *Config {
-	return NewConfig()
+
+func NewConfig() *Config {
+	return newConfig(viper.New(), nil)
+}
+func LoadConfig(paths ...string) (*Config, error) {
+	load := withSecrets(func() (*viper.Viper, error) {
+		return readFiles(paths...)
+	})
+	_viper_, err := load()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, errors.WithStackIf(err)
+		}
+		files = append(files, absPath)
+	}
+	c := newConfig(_viper_, load, files...)
+	c.watch()
+	return c, nil
 }
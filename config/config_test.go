@@ -0,0 +1,242 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "application.yml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("web:\n  log:\n    level: info\n")
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	changed := make(chan [2]any, 1)
+	c.Subscribe("web.log.level", func(old, new any) {
+		changed <- [2]any{old, new}
+	})
+	c.AddValidator(func(config IConfig) error {
+		if config.GetString("web.log.level") == "bad" {
+			return errors.New("bad level")
+		}
+		return nil
+	})
+
+	write("web:\n  log:\n    level: bad\n")
+	if err := c.Reload(); err == nil {
+		t.Fatal("invalid config should be rejected")
+	}
+	if level := c.GetString("web.log.level"); level != "info" {
+		t.Fatalf("old config should be kept, got %q", level)
+	}
+
+	write("web:\n  log:\n    level: debug\n")
+	select {
+	case values := <-changed:
+		if values[0] != "info" || values[1] != "debug" {
+			t.Fatalf("unexpected change %v", values)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config change was not observed")
+	}
+	if level := c.GetString("web.log.level"); level != "debug" {
+		t.Fatalf("expected debug, got %q", level)
+	}
+}
+
+func TestLoadAutoConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	files := map[string]string{
+		"application.yml":             "web:\n  server:\n    port: 1\n    host: a\n  log:\n    level: info\nrate_limit:\n  limit: 1\n",
+		"config/application.yml":      "web:\n  server:\n    port: 2\n",
+		"config/application-prod.yml": "web:\n  server:\n    port: 3\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("WEB_PROFILE", "prod")
+	t.Setenv("WEB_LOG_LEVEL", "debug")
+	t.Setenv("RATE_LIMIT_LIMIT", "5")
+	t.Setenv("WEB_SCHEDULE_ENABLE", "true")
+	t.Setenv("WEB_SERVER_FOO", "x")
+	t.Setenv("WEB_SERVER", "x")
+	t.Setenv("WEB_SCHEDULE_RETRY_TIMES", "3")
+	Register("web.schedule", &struct {
+		Enable bool
+		Retry  struct{ Times int }
+	}{})
+	args := os.Args
+	os.Args = []string{args[0], "serve", "--web.server.host=b"}
+	defer func() { os.Args = args }()
+
+	c, err := LoadAutoConfigE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	expected := map[string]string{
+		"web.server.port":          "3",
+		"web.server.host":          "b",
+		"web.log.level":            "debug",
+		"rate_limit.limit":         "5",
+		"web.schedule.enable":      "true",
+		"web.schedule.retry.times": "3",
+	}
+	for key, value := range expected {
+		if actual := c.GetString(key); actual != value {
+			t.Errorf("%s: expected %q, got %q", key, value, actual)
+		}
+	}
+	if c.HasKey("web.server.foo") {
+		t.Error("environment variables should not add keys that are not in the config files or registered")
+	}
+	var server struct {
+		Port int
+		Host string
+	}
+	if err := c.Unmarshal("web.server", &server); err != nil {
+		t.Fatal(err)
+	}
+	if server.Port != 3 || server.Host != "b" {
+		t.Fatalf("unexpected server config %+v", server)
+	}
+}
+
+type testServerConfig struct {
+	Port int `validate:"required,min=1,max=65535"`
+	SSL  *struct {
+		Hosts []string `validate:"min=1"`
+	}
+}
+
+type testCaptchaConfig struct {
+	CodeKey string `validate:"required,min=32"`
+	Mode    string `validate:"oneof=slide click"`
+}
+
+func (c *testCaptchaConfig) Validate() error {
+	if c.Mode == "click" {
+		return errors.New("click mode is not supported")
+	}
+	return nil
+}
+
+func TestUnmarshalValidate(t *testing.T) {
+	c := NewConfig()
+	c.Put("web.server.port", 70000)
+	c.Put("web.server.prot", 8080)
+	c.Put("web.server.ssl.hosts", []string{"localhost"})
+	c.Put("web.server.ssl.enable", true)
+	var server testServerConfig
+	err := c.Unmarshal("web.server", &server)
+	if err == nil || err.Error() != "web.server.port: must be 1-65535" {
+		t.Fatalf("unknown keys should only be checked by CheckUnknownKeys, got %v", err)
+	}
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) || len(validationError.Errors) != 1 {
+		t.Fatalf("expected *ValidationError with 1 error, got %#v", err)
+	}
+	err = CheckUnknownKeys(c, "web.server", &server)
+	expected := "web.server.prot: unknown key; web.server.ssl.enable: unknown key"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected %q, got %v", expected, err)
+	}
+	if err := CheckUnknownKeys(c, "web.server", &server, "prot", "ssl"); err != nil {
+		t.Fatalf("ignored keys should not be reported, got %v", err)
+	}
+
+	var captcha testCaptchaConfig
+	err = c.Unmarshal("captcha", &captcha)
+	if err == nil || err.Error() != "captcha.codeKey: required, min 32 chars" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	c.Put("captcha.codeKey", "0123456789abcdef0123456789abcdef")
+	c.Put("captcha.mode", "drag")
+	err = c.Unmarshal("captcha", &captcha)
+	if err == nil || err.Error() != "captcha.mode: must be one of slide, click" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	c.Put("captcha.mode", "click")
+	err = c.Unmarshal("captcha", &captcha)
+	if err == nil || err.Error() != "captcha: click mode is not supported" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	t.Setenv(MasterKeyEnv, "master-key")
+	dir := t.TempDir()
+	password, err := Encrypt("p@ss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretPath := filepath.Join(dir, "token")
+	if err := os.WriteFile(secretPath, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "application.yml")
+	dsn := "file:/data/app.db?cache=shared&mode=rwc"
+	content := "web:\n  db:\n    password: " + password + "\n    token: FILE(" + secretPath + ")\n    host: localhost\n    dsn: \"" + dsn + "\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadSingleFileConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if v := c.GetString("web.db.password"); v != "p@ss" {
+		t.Fatalf("expected decrypted password, got %q", v)
+	}
+	var db struct {
+		Password string
+		Token    string
+		Host     string
+		Dsn      string
+	}
+	if err := c.Unmarshal("web.db", &db); err != nil {
+		t.Fatal(err)
+	}
+	if db.Password != "p@ss" || db.Token != "s3cret" || db.Host != "localhost" || db.Dsn != dsn {
+		t.Fatalf("unexpected config %+v", db)
+	}
+	masked := MaskSecrets(c.AllSettings())["web"].(map[string]any)["db"].(map[string]any)
+	if masked["dsn"] != dsn || masked["token"] != Mask {
+		t.Fatalf("only secrets should be masked: %v", masked)
+	}
+	if err := c.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), password) || strings.Contains(string(data), "p@ss") {
+		t.Fatalf("plaintext should not be written back: %s", data)
+	}
+
+	t.Setenv(MasterKeyEnv, "wrong-key")
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("loading with a wrong master key should fail")
+	}
+}
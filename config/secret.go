@@ -0,0 +1,197 @@
+package config
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/util"
+	"github.com/spf13/viper"
+)
+
+const (
+	// MasterKeyEnv 解密 ENC(...) 配置值的主密钥
+	MasterKeyEnv = "WEB_CONFIG_KEY"
+	// MasterKeyFileEnv 主密钥文件路径，未设置 WEB_CONFIG_KEY 时读取
+	MasterKeyFileEnv = "WEB_CONFIG_KEY_FILE"
+
+	encPrefix  = "ENC("
+	encSuffix  = ")"
+	filePrefix = "FILE("
+
+	// Mask 替换敏感配置值
+	Mask = "******"
+)
+
+// sensitiveWords 配置项名称包含这些词时视为敏感值
+var sensitiveWords = []string{"password", "passwd", "secret", "token", "key", "credential"}
+
+// MasterKey 读取主密钥，任意长度的密钥经 SHA-256 后作为 AES-256 的密钥
+func MasterKey() (string, error) {
+	key := os.Getenv(MasterKeyEnv)
+	if len(key) == 0 {
+		path := os.Getenv(MasterKeyFileEnv)
+		if len(path) == 0 {
+			return "", errors.Errorf("master key not found, set %s or %s", MasterKeyEnv, MasterKeyFileEnv)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", errors.WrapIff(err, "read master key file %s", path)
+		}
+		key = strings.TrimSpace(string(data))
+	}
+	if len(key) == 0 {
+		return "", errors.New("master key is empty")
+	}
+	sum := sha256.Sum256([]byte(key))
+	return string(sum[:]), nil
+}
+
+// Encrypt 使用主密钥加密，返回可直接写入配置文件的 ENC(...)
+func Encrypt(plaintext string) (string, error) {
+	key, err := MasterKey()
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := util.EncryptByGCM(plaintext, key)
+	if err != nil {
+		return "", err
+	}
+	return encPrefix + ciphertext + encSuffix, nil
+}
+
+// resolveSecret 解密 ENC(...)，读取 FILE(/path) 引用的文件，其他值原样返回，
+// sqlite 的 file: 连接串等普通的值不会被当作文件引用
+func resolveSecret(value string) (string, bool, error) {
+	if strings.HasPrefix(value, encPrefix) && strings.HasSuffix(value, encSuffix) {
+		key, err := MasterKey()
+		if err != nil {
+			return "", true, err
+		}
+		plaintext, err := util.DecryptByGCM(value[len(encPrefix):len(value)-len(encSuffix)], key)
+		return plaintext, true, err
+	}
+	if strings.HasPrefix(value, filePrefix) && strings.HasSuffix(value, encSuffix) {
+		path := value[len(filePrefix) : len(value)-len(encSuffix)]
+		if !filepath.IsAbs(path) {
+			return "", true, errors.Errorf("secret file %s must be an absolute path", path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", true, errors.WithStackIf(err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+	return value, false, nil
+}
+
+// resolveSecrets 返回解密后的副本，不修改原配置，避免明文被 WriteConfig 写回文件
+func resolveSecrets(key string, value any) (any, bool, error) {
+	switch v := value.(type) {
+	case string:
+		resolved, ok, err := resolveSecret(v)
+		if err != nil {
+			return nil, ok, errors.WrapIff(err, "resolve %s", key)
+		}
+		return resolved, ok, nil
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		changed := false
+		var errs []error
+		for k, item := range v {
+			resolved, ok, err := resolveSecrets(joinKey(key, k), item)
+			errs = append(errs, err)
+			changed = changed || ok
+			m[k] = resolved
+		}
+		return m, changed, errors.Combine(errs...)
+	case []any:
+		s := make([]any, len(v))
+		changed := false
+		var errs []error
+		for i, item := range v {
+			resolved, ok, err := resolveSecrets(key, item)
+			errs = append(errs, err)
+			changed = changed || ok
+			s[i] = resolved
+		}
+		return s, changed, errors.Combine(errs...)
+	case []string:
+		s := make([]string, len(v))
+		changed := false
+		var errs []error
+		for i, item := range v {
+			resolved, ok, err := resolveSecret(item)
+			errs = append(errs, errors.WrapIff(err, "resolve %s", key))
+			changed = changed || ok
+			s[i] = resolved
+		}
+		return s, changed, errors.Combine(errs...)
+	default:
+		return value, false, nil
+	}
+}
+
+// withSecrets 加载配置后检查所有加密值和文件引用，无法解析时加载失败
+func withSecrets(load func() (*viper.Viper, error)) func() (*viper.Viper, error) {
+	return func() (*viper.Viper, error) {
+		v, err := load()
+		if err != nil {
+			return nil, err
+		}
+		_, _, err = resolveSecrets("", v.AllSettings())
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// MaskSecrets 返回屏蔽敏感值后的副本，名称包含 password、secret、key 等词的配置项以及 ENC(...)、FILE(/path) 的值替换为 Mask
+func MaskSecrets(settings map[string]any) map[string]any {
+	return maskSecrets("", settings).(map[string]any)
+}
+
+func maskSecrets(name string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[k] = maskSecrets(k, item)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, item := range v {
+			s[i] = maskSecrets(name, item)
+		}
+		return s
+	case string:
+		if isSensitive(name) || isSecretRef(v) {
+			return Mask
+		}
+		return v
+	default:
+		if v != nil && isSensitive(name) {
+			return Mask
+		}
+		return v
+	}
+}
+
+// isSecretRef 值为 ENC(...) 或 FILE(/path)
+func isSecretRef(value string) bool {
+	return strings.HasSuffix(value, encSuffix) && (strings.HasPrefix(value, encPrefix) || strings.HasPrefix(value, filePrefix))
+}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
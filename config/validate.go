@@ -0,0 +1,344 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+)
+
+// ValidateTag 配置字段的校验标签，多个规则用逗号分隔，例如 validate:"required,min=1,max=65535"
+//
+//	required   不能为零值
+//	min=N      数字不小于 N，字符串长度、切片和 map 元素个数不少于 N
+//	max=N      数字不大于 N，字符串长度、切片和 map 元素个数不超过 N
+//	oneof=a b  只能是其中之一，字符串忽略大小写
+//
+// 未设置 required 的字段为零值时不校验其他规则，零值表示使用默认值
+const ValidateTag = "validate"
+
+// Validator 配置结构体可实现该接口，在标签校验之后调用
+type Validator interface {
+	Validate() error
+}
+
+// FieldError 单个配置项的错误，Key 为完整的配置路径，例如 web.server.port
+type FieldError struct {
+	Key     string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Key + ": " + e.Message
+}
+
+// ValidationError 一次 Unmarshal 中所有配置项的错误
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		messages[i] = fieldError.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) add(key string, message string) {
+	e.Errors = append(e.Errors, &FieldError{Key: key, Message: message})
+}
+
+func (e *ValidationError) orNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// validate 检查校验标签以及 Validator
+func validate(key string, v any) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	validationError := &ValidationError{}
+	validateStruct(key, value, validationError)
+	if validator, ok := v.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			var fieldErrors *ValidationError
+			if errors.As(err, &fieldErrors) {
+				validationError.Errors = append(validationError.Errors, fieldErrors.Errors...)
+			} else {
+				validationError.add(key, err.Error())
+			}
+		}
+	}
+	return validationError.orNil()
+}
+
+// CheckUnknownKeys 检查 key 下是否有 v 中不存在的配置项，ignore 中的配置项不检查，
+// 只用于框架自身的配置，应用的配置可以只读取其中一部分
+func CheckUnknownKeys(c IConfig, key string, v any, ignore ...string) error {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	var settings any = c.AllSettings()
+	for _, name := range strings.Split(strings.ToLower(key), ".") {
+		m, ok := settings.(map[string]any)
+		if !ok {
+			return nil
+		}
+		settings = m[name]
+	}
+	m, ok := settings.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if len(ignore) > 0 {
+		m = maps.Clone(m)
+		for _, name := range ignore {
+			delete(m, strings.ToLower(name))
+		}
+	}
+	validationError := &ValidationError{}
+	unknownKeys(key, m, t, validationError)
+	return validationError.orNil()
+}
+
+// fieldName 与 viper 解析时一致，优先使用 mapstructure 标签
+func fieldName(field reflect.StructField) (name string, squash bool) {
+	tag := field.Tag.Get("mapstructure")
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return "", false
+	}
+	squash = field.Anonymous && strings.Contains(options, "squash")
+	if len(name) == 0 {
+		name = field.Name
+	}
+	return name, squash
+}
+
+// displayName 错误信息中的字段名，开头的大写字母转为小写，例如 CodeKey 为 codeKey，SSL 为 ssl
+func displayName(name string) string {
+	upper := 0
+	for upper < len(name) && name[upper] >= 'A' && name[upper] <= 'Z' {
+		upper++
+	}
+	if upper > 1 && upper < len(name) {
+		upper--
+	}
+	return strings.ToLower(name[:upper]) + name[upper:]
+}
+
+func joinKey(key string, name string) string {
+	if len(key) == 0 {
+		return name
+	}
+	return key + "." + name
+}
+
+type structField struct {
+	name  string
+	field reflect.StructField
+}
+
+// structFields 按声明顺序返回可解析的字段，squash 的嵌入结构体展开
+func structFields(t reflect.Type) []structField {
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, squash := fieldName(field)
+		if squash {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			for _, f := range structFields(ft) {
+				f.field.Index = append(slices.Clone(field.Index), f.field.Index...)
+				fields = append(fields, f)
+			}
+			continue
+		}
+		if len(name) > 0 {
+			fields = append(fields, structField{name: name, field: field})
+		}
+	}
+	return fields
+}
+
+func unknownKeys(key string, settings map[string]any, t reflect.Type, validationError *ValidationError) {
+	fields := structFields(t)
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		i := slices.IndexFunc(fields, func(f structField) bool {
+			return strings.EqualFold(f.name, name)
+		})
+		if i < 0 {
+			validationError.add(joinKey(key, name), "unknown key")
+			continue
+		}
+		m, ok := settings[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		ft := fields[i].field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			unknownKeys(joinKey(key, displayName(fields[i].name)), m, ft, validationError)
+		}
+	}
+}
+
+func validateStruct(key string, value reflect.Value, validationError *ValidationError) {
+	for _, f := range structFields(value.Type()) {
+		fieldValue := value.FieldByIndex(f.field.Index)
+		fieldKey := joinKey(key, displayName(f.name))
+		if rules, ok := f.field.Tag.Lookup(ValidateTag); ok {
+			if message := checkRules(fieldValue, rules); len(message) > 0 {
+				validationError.add(fieldKey, message)
+			}
+		}
+		validateNested(fieldKey, fieldValue, validationError)
+		if fieldValue.Kind() == reflect.Slice {
+			for i := 0; i < fieldValue.Len(); i++ {
+				validateNested(fieldKey+"["+strconv.Itoa(i)+"]", fieldValue.Index(i), validationError)
+			}
+		}
+	}
+}
+
+// validateNested 校验嵌套的结构体，包括嵌套结构体实现的 Validator
+func validateNested(key string, value reflect.Value, validationError *ValidationError) {
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return
+	}
+	validateStruct(key, value, validationError)
+	if !value.CanAddr() {
+		return
+	}
+	if validator, ok := value.Addr().Interface().(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			validationError.add(key, err.Error())
+		}
+	}
+}
+
+// checkRules 返回所有未通过的规则，例如 "required, min 32 chars"
+func checkRules(value reflect.Value, rules string) string {
+	parsed := make(map[string]string)
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if len(name) > 0 {
+			parsed[name] = arg
+		}
+	}
+	_, required := parsed["required"]
+	if value.IsZero() {
+		if required {
+			return strings.Join(describeRules(value, parsed), ", ")
+		}
+		return ""
+	}
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	failed := false
+	if arg, ok := parsed["oneof"]; ok {
+		options := strings.Fields(arg)
+		actual := fmt.Sprint(value.Interface())
+		failed = !slices.ContainsFunc(options, func(option string) bool {
+			return strings.EqualFold(option, actual)
+		})
+	}
+	size, _ := measure(value)
+	if arg, ok := parsed["min"]; ok {
+		if limit, err := strconv.ParseFloat(arg, 64); err == nil && size < limit {
+			failed = true
+		}
+	}
+	if arg, ok := parsed["max"]; ok {
+		if limit, err := strconv.ParseFloat(arg, 64); err == nil && size > limit {
+			failed = true
+		}
+	}
+	if !failed {
+		return ""
+	}
+	delete(parsed, "required")
+	return strings.Join(describeRules(value, parsed), ", ")
+}
+
+// measure 数字返回数值，字符串、切片和 map 返回长度
+func measure(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len()), false
+	default:
+		return 0, false
+	}
+}
+
+func describeRules(value reflect.Value, rules map[string]string) []string {
+	for value.Kind() == reflect.Ptr {
+		value = reflect.New(value.Type().Elem()).Elem()
+	}
+	_, numeric := measure(value)
+	unit := "items"
+	if value.Kind() == reflect.String {
+		unit = "chars"
+	}
+	descriptions := make([]string, 0, len(rules))
+	if _, ok := rules["required"]; ok {
+		descriptions = append(descriptions, "required")
+	}
+	minArg, hasMin := rules["min"]
+	maxArg, hasMax := rules["max"]
+	switch {
+	case numeric && hasMin && hasMax:
+		descriptions = append(descriptions, "must be "+minArg+"-"+maxArg)
+	case numeric && hasMin:
+		descriptions = append(descriptions, "must be >= "+minArg)
+	case numeric && hasMax:
+		descriptions = append(descriptions, "must be <= "+maxArg)
+	default:
+		if hasMin {
+			descriptions = append(descriptions, "min "+minArg+" "+unit)
+		}
+		if hasMax {
+			descriptions = append(descriptions, "max "+maxArg+" "+unit)
+		}
+	}
+	if arg, ok := rules["oneof"]; ok {
+		descriptions = append(descriptions, "must be one of "+strings.Join(strings.Fields(arg), ", "))
+	}
+	return descriptions
+}
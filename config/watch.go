@@ -0,0 +1,174 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/log"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sourcegraph/conc/panics"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// SubscribeFunc 配置项变化时回调，old 和 new 为变化前后的值，key 为空时为全部配置
+type SubscribeFunc func(old, new any)
+
+// ValidateFunc 校验重新加载后的配置，返回错误时本次重新加载被拒绝，保留原配置
+type ValidateFunc func(config IConfig) error
+
+// reloadDelay 编辑器保存文件时会连续触发多个事件，合并后只重新加载一次
+const reloadDelay = 100 * time.Millisecond
+
+type subscriber struct {
+	key string
+	fn  SubscribeFunc
+}
+
+// Subscribe 订阅配置项变化，key 为空时任意配置变化都会回调
+func (c *Config) Subscribe(key string, fn SubscribeFunc) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.subscribers = append(c.subscribers, &subscriber{key: key, fn: fn})
+}
+
+// AddValidator 添加重新加载时的校验，所有校验通过后新配置才会生效
+func (c *Config) AddValidator(fn ValidateFunc) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.validators = append(c.validators, fn)
+}
+
+// Reload 重新读取配置文件，校验失败时保留原配置并返回错误，成功后通知订阅者
+func (c *Config) Reload() error {
+	if c.load == nil {
+		return nil
+	}
+	v, err := c.load()
+	if err != nil {
+		return errors.WrapIf(err, "reload config rejected")
+	}
+	c.lock.RLock()
+	for key, value := range c.overrides {
+		v.Set(key, value)
+	}
+	validators := slices.Clone(c.validators)
+	c.lock.RUnlock()
+
+	candidate := newConfig(v, nil)
+	var errs []error
+	for _, validate := range validators {
+		if err := validate(candidate); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.WrapIf(errors.Combine(errs...), "reload config rejected")
+	}
+
+	c.lock.Lock()
+	old := c.v
+	c.v = v
+	subscribers := slices.Clone(c.subscribers)
+	c.lock.Unlock()
+	log.Info("Reload the configuration", zap.Strings("files", c.files))
+	for _, s := range subscribers {
+		oldValue, newValue := settingOf(old, s.key), settingOf(v, s.key)
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		var catcher panics.Catcher
+		catcher.Try(func() {
+			s.fn(oldValue, newValue)
+		})
+		if recovered := catcher.Recovered(); recovered != nil {
+			log.Error("config subscriber panic", zap.String("key", s.key), zap.Error(recovered.AsError()))
+		}
+	}
+	return nil
+}
+
+func settingOf(v *viper.Viper, key string) any {
+	if key == "" {
+		return v.AllSettings()
+	}
+	return v.Get(key)
+}
+
+// watch 监听配置文件所在目录，文件变化后重新加载，监听失败只记录日志
+func (c *Config) watch() {
+	if len(c.files) == 0 {
+		return
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warn("Failed to watch the configuration", zap.Error(err))
+		return
+	}
+	dirs := make([]string, 0, len(c.files))
+	for _, file := range c.files {
+		dir := filepath.Dir(file)
+		if slices.Contains(dirs, dir) {
+			continue
+		}
+		dirs = append(dirs, dir)
+		if err := watcher.Add(dir); err != nil {
+			log.Warn("Failed to watch the configuration", zap.String("dir", dir), zap.Error(err))
+		}
+	}
+	c.lock.Lock()
+	c.watcher = watcher
+	c.lock.Unlock()
+	go c.watchLoop(watcher)
+}
+
+func (c *Config) watchLoop(watcher *fsnotify.Watcher) {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			if !slices.Contains(c.files, filepath.Clean(event.Name)) {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(reloadDelay, func() {
+				if err := c.Reload(); err != nil {
+					log.Error("Failed to reload the configuration", zap.Error(err))
+				}
+			})
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warn("config watcher", zap.Error(err))
+		}
+	}
+}
+
+// Close 停止监听配置文件
+func (c *Config) Close() error {
+	c.lock.Lock()
+	watcher := c.watcher
+	c.watcher = nil
+	c.lock.Unlock()
+	if watcher == nil {
+		return nil
+	}
+	return errors.WithStackIf(watcher.Close())
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"sync"
 
+	"emperror.dev/errors"
 	config2 "github.com/chuccp/go-web-frame/config"
 	"github.com/chuccp/go-web-frame/db"
 	"github.com/chuccp/go-web-frame/log"
@@ -17,29 +18,31 @@ import (
 type Context struct {
 	config            config2.IConfig
 	httpServer        *web.HttpServer
-	modelMap          map[string]IModel
+	router            *gin.RouterGroup
+	models            *registry[IModel]
 	rLock             *sync.RWMutex
-	serviceMap        map[string]IService
-	componentMap      map[string]IComponent
+	services          *registry[IService]
+	components        *registry[IComponent]
 	digestAuth        *web.DigestAuth
 	schedule          *Schedule
 	routeTree         RouteTree
-	runnerMap         map[string]IRunner
+	routes            *routeTable
+	runners           *registry[IRunner]
 	defaultModelGroup IModelGroup
 	modelGroup        map[string]IModelGroup
 }
 
 func NewContext(config config2.IConfig, schedule *Schedule, defaultModelGroup IModelGroup) *Context {
 	context := &Context{
-		config:       config,
-		modelMap:     make(map[string]IModel),
-		rLock:        new(sync.RWMutex),
-		serviceMap:   make(map[string]IService),
-		componentMap: make(map[string]IComponent),
-		//transaction:  model.NewTransaction(db),
-		runnerMap:         make(map[string]IRunner),
+		config:            config,
+		models:            newRegistry[IModel](),
+		rLock:             new(sync.RWMutex),
+		services:          newRegistry[IService](),
+		components:        newRegistry[IComponent](),
+		runners:           newRegistry[IRunner](),
 		schedule:          schedule,
 		routeTree:         make(RouteTree),
+		routes:            &routeTable{},
 		modelGroup:        make(map[string]IModelGroup),
 		defaultModelGroup: defaultModelGroup,
 	}
@@ -50,20 +53,39 @@ func (c *Context) Copy(digestAuth *web.DigestAuth, httpServer *web.HttpServer) *
 	context := &Context{
 		config:            c.config,
 		httpServer:        httpServer,
-		modelMap:          c.modelMap,
+		router:            httpServer.Group(""),
+		models:            c.models,
 		rLock:             c.rLock,
-		serviceMap:        c.serviceMap,
+		services:          c.services,
 		digestAuth:        digestAuth,
-		componentMap:      c.componentMap,
+		components:        c.components,
 		schedule:          c.schedule,
 		routeTree:         make(RouteTree),
-		runnerMap:         c.runnerMap,
+		routes:            c.routes,
+		runners:           c.runners,
 		modelGroup:        c.modelGroup,
 		defaultModelGroup: c.defaultModelGroup,
 	}
 	return context
 }
 
+// Group 创建路径前缀为 relativePath 的子 Context，子 Context 注册的路由和中间件都在该前缀下，
+// digestAuth 为 nil 时沿用当前的认证
+func (c *Context) Group(relativePath string, digestAuth *web.DigestAuth) *Context {
+	context := *c
+	if digestAuth != nil {
+		context.digestAuth = digestAuth
+	}
+	context.router = c.router.Group(relativePath)
+	context.routeTree = make(RouteTree)
+	return &context
+}
+
+// BasePath 返回当前 Context 的路由前缀
+func (c *Context) BasePath() string {
+	return c.router.BasePath()
+}
+
 func (c *Context) GetTransaction() *model.Transaction {
 	return c.defaultModelGroup.GetTransaction()
 }
@@ -71,21 +93,17 @@ func (c *Context) GetSchedule() *Schedule {
 	return c.schedule
 }
 func (c *Context) AddModel(model ...IModel) {
-	c.rLock.Lock()
-	defer c.rLock.Unlock()
-	for _, m := range model {
-		name := util.GetStructFullName(m)
-		c.modelMap[name] = m
-	}
+	c.models.add(model...)
+}
+func (c *Context) AddNamedModel(name string, model IModel) {
+	c.models.addNamed(name, model)
 }
 
 func (c *Context) AddRunner(runner ...IRunner) {
-	c.rLock.Lock()
-	defer c.rLock.Unlock()
-	for _, r := range runner {
-		name := util.GetStructFullName(r)
-		c.runnerMap[name] = r
-	}
+	c.runners.add(runner...)
+}
+func (c *Context) AddNamedRunner(name string, runner IRunner) {
+	c.runners.addNamed(name, runner)
 }
 
 func (c *Context) AddModelGroup(modelGroup ...IModelGroup) {
@@ -111,26 +129,22 @@ func (c *Context) GetModelGroup(name string) IModelGroup {
 }
 
 func (c *Context) AddComponent(components ...IComponent) {
-	c.rLock.Lock()
-	defer c.rLock.Unlock()
-	for _, component := range components {
-		name := util.GetStructFullName(component)
-		c.componentMap[name] = component
-	}
+	c.components.add(components...)
+}
+func (c *Context) AddNamedComponent(name string, component IComponent) {
+	c.components.addNamed(name, component)
 }
 
 func (c *Context) AddService(services ...IService) {
-	c.rLock.Lock()
-	defer c.rLock.Unlock()
-	for _, s := range services {
-		name := util.GetStructFullName(s)
-		c.serviceMap[name] = s
-	}
+	c.services.add(services...)
+}
+func (c *Context) AddNamedService(name string, service IService) {
+	c.services.addNamed(name, service)
 }
+
+// GetRunner 按注册顺序返回第一个满足 f 的 runner
 func (c *Context) GetRunner(f func(m IRunner) bool) IRunner {
-	c.rLock.RLock()
-	defer c.rLock.RUnlock()
-	for _, r := range c.runnerMap {
+	for _, r := range c.runners.all() {
 		if f(r) {
 			return r
 		}
@@ -138,30 +152,29 @@ func (c *Context) GetRunner(f func(m IRunner) bool) IRunner {
 	return nil
 }
 
+// GetService 按注册顺序返回第一个满足 f 的 service
 func (c *Context) GetService(f func(m IService) bool) IService {
-	c.rLock.RLock()
-	defer c.rLock.RUnlock()
-	for _, s := range c.serviceMap {
+	for _, s := range c.services.all() {
 		if f(s) {
 			return s
 		}
 	}
 	return nil
 }
+
+// GetComponent 按注册顺序返回第一个满足 f 的 component
 func (c *Context) GetComponent(f func(m IComponent) bool) IComponent {
-	c.rLock.RLock()
-	defer c.rLock.RUnlock()
-	for _, s := range c.componentMap {
+	for _, s := range c.components.all() {
 		if f(s) {
 			return s
 		}
 	}
 	return nil
 }
+
+// GetModel 按注册顺序返回第一个满足 f 的 model
 func (c *Context) GetModel(f func(m IModel) bool) IModel {
-	c.rLock.RLock()
-	defer c.rLock.RUnlock()
-	for _, m := range c.modelMap {
+	for _, m := range c.models.all() {
 		if f(m) {
 			return m
 		}
@@ -169,39 +182,66 @@ func (c *Context) GetModel(f func(m IModel) bool) IModel {
 	return nil
 }
 
+// Use 添加中间件，只作用于当前 Context 路由前缀下之后注册的路由
 func (c *Context) Use(middlewareFunc ...MiddlewareFunc) {
 	for _, middlewareFunc := range middlewareFunc {
-		c.httpServer.Use(func(ctx *gin.Context) {
-			if c.routeTree.Has(ctx.Request.Method, ctx.FullPath()) {
-				middlewareFunc(web.NewRequest(ctx, c.digestAuth), c)
-			}
-		})
+		c.UseMiddleware(middlewareFunc.Middleware())
+	}
+}
+
+// Cors 设置当前 Context 路径前缀下的跨域配置，在路由匹配前执行，未注册 OPTIONS 路由时也能响应预检请求
+func (c *Context) Cors(config *web.CorsConfig) {
+	c.httpServer.Cors(c.router.BasePath(), config)
+}
+
+// Security 设置当前 Context 路径前缀下的安全响应头
+func (c *Context) Security(config *web.SecurityConfig) {
+	c.httpServer.Security(c.router.BasePath(), config)
+}
+
+// UseMiddleware 添加可中止请求的中间件，按添加顺序执行，只作用于当前 Context 路由前缀下之后注册的路由
+func (c *Context) UseMiddleware(middleware ...Middleware) {
+	for _, m := range middleware {
+		c.router.Use(web.ToGinMiddleware(c.digestAuth, func(request *web.Request) (any, error) {
+			return m(request, c)
+		}))
 	}
 }
 
-func (c *Context) ginHandler(httpMethod string, relativePath string, handlers ...gin.HandlerFunc) {
-	c.routeTree.Set(httpMethod, relativePath)
-	c.httpServer.Handle(httpMethod, relativePath, handlers...)
+func (c *Context) ginHandler(httpMethod string, relativePath string, handlerName string, auth bool, handlers ...gin.HandlerFunc) {
+	fullPath := joinPaths(c.router.BasePath(), relativePath)
+	c.routeTree.Set(httpMethod, fullPath)
+	c.routes.add(Route{Port: c.httpServer.Port(), Method: httpMethod, Path: fullPath, Handler: handlerName, Auth: auth})
+	c.router.Handle(httpMethod, relativePath, handlers...)
 }
 
 func (c *Context) authHandle(httpMethod, relativePath string, handlers ...web.HandlerFunc) {
-	log.Debug("authHandle", zap.String("method", httpMethod), zap.String("path", relativePath), zap.Any("handlers", web.Of(handlers...).GetFuncName()))
-	c.ginHandler(httpMethod, relativePath, web.ToGinHandlerFunc(c.digestAuth, web.AuthChecks(handlers...)...)...)
+	name := web.Of(handlers...).GetFuncName()
+	log.Debug("authHandle", zap.String("method", httpMethod), zap.String("path", relativePath), zap.Any("handlers", name))
+	c.ginHandler(httpMethod, relativePath, name, true, web.ToGinHandlerFunc(c.digestAuth, web.AuthChecks(handlers...)...)...)
 }
 
 func (c *Context) handle(httpMethod, relativePath string, handlers ...web.HandlerFunc) {
-	log.Debug("handle", zap.String("method", httpMethod), zap.String("path", relativePath), zap.Any("handlers", web.Of(handlers...).GetFuncName()))
-	c.ginHandler(httpMethod, relativePath, web.ToGinHandlerFunc(c.digestAuth, handlers...)...)
+	name := web.Of(handlers...).GetFuncName()
+	log.Debug("handle", zap.String("method", httpMethod), zap.String("path", relativePath), zap.Any("handlers", name))
+	c.ginHandler(httpMethod, relativePath, name, false, web.ToGinHandlerFunc(c.digestAuth, handlers...)...)
 }
 
 func (c *Context) handleRaw(httpMethod, relativePath string, handlers ...web.HandlerRawFunc) {
-	log.Debug("rawHandle", zap.String("method", httpMethod), zap.String("path", relativePath), zap.Any("handlers", web.OfRaw(handlers...).GetFuncName()))
-	c.ginHandler(httpMethod, relativePath, web.ToGinHandlerRawFunc(c.digestAuth, handlers...)...)
+	name := web.OfRaw(handlers...).GetFuncName()
+	log.Debug("rawHandle", zap.String("method", httpMethod), zap.String("path", relativePath), zap.Any("handlers", name))
+	c.ginHandler(httpMethod, relativePath, name, false, web.ToGinHandlerRawFunc(c.digestAuth, handlers...)...)
 }
 
 func (c *Context) authHandleRaw(httpMethod, relativePath string, handlers ...web.HandlerRawFunc) {
-	log.Debug("authRawHandle", zap.String("method", httpMethod), zap.String("path", relativePath), zap.Any("handlers", web.OfRaw(handlers...).GetFuncName()))
-	c.ginHandler(httpMethod, relativePath, web.ToGinHandlerRawFunc(c.digestAuth, web.AuthRawChecks(handlers...)...)...)
+	name := web.OfRaw(handlers...).GetFuncName()
+	log.Debug("authRawHandle", zap.String("method", httpMethod), zap.String("path", relativePath), zap.Any("handlers", name))
+	c.ginHandler(httpMethod, relativePath, name, true, web.ToGinHandlerRawFunc(c.digestAuth, web.AuthRawChecks(handlers...)...)...)
+}
+
+// Routes 返回所有端口上已注册的路由
+func (c *Context) Routes() []Route {
+	return c.routes.all()
 }
 
 func (c *Context) HandleAuth(httpMethod, relativePath string, handlers ...web.HandlerFunc) {
@@ -307,49 +347,89 @@ func (c *Context) PutRawAuth(relativePath string, handlers ...web.HandlerRawFunc
 	c.authHandleRaw(http.MethodPut, relativePath, handlers...)
 }
 
+// FindService 查找类型为 T 的 service，未注册或匹配到多个时返回错误
+func FindService[T IService](c *Context) (T, error) {
+	return findTyped[T](c.services)
+}
+
+// GetService 查找类型为 T 的 service，未注册或匹配到多个时返回零值
 func GetService[T IService](c *Context) T {
-	t, _ := c.GetService(func(m IService) bool {
-		_, ok := m.(T)
-		return ok
-	}).(T)
+	t, err := FindService[T](c)
+	logLookupError(err)
+	return t
+}
+
+func GetServiceNamed[T IService](c *Context, name string) T {
+	t, err := findNamed[T](c.services, name)
+	logLookupError(err)
 	return t
 }
 
+func FindModel[T IModel](c *Context) (T, error) {
+	return findTyped[T](c.models)
+}
+
 func GetModel[T IModel](c *Context) T {
-	t, _ := c.GetModel(func(m IModel) bool {
-		_, ok := m.(T)
-		return ok
-	}).(T)
+	t, err := FindModel[T](c)
+	logLookupError(err)
+	return t
+}
+
+func GetModelNamed[T IModel](c *Context, name string) T {
+	t, err := findNamed[T](c.models, name)
+	logLookupError(err)
 	return t
 }
 
 func GetReNewModel[T IModel](db *db.DB, c *Context) T {
-	t, ok := c.GetModel(func(m IModel) bool {
-		_, ok := m.(T)
-		return ok
-	}).(T)
-	if ok {
-		t, _ = t.ReNew(db, c).(T)
+	t, err := FindModel[T](c)
+	if err != nil {
+		logLookupError(err)
 		return t
 	}
+	t, _ = t.ReNew(db, c).(T)
 	return t
 }
+
+func FindComponent[T IComponent](c *Context) (T, error) {
+	return findTyped[T](c.components)
+}
+
 func GetComponent[T IComponent](c *Context) T {
-	t, _ := c.GetComponent(func(m IComponent) bool {
-		_, ok := m.(T)
-		return ok
-	}).(T)
+	t, err := FindComponent[T](c)
+	logLookupError(err)
+	return t
+}
+
+func GetComponentNamed[T IComponent](c *Context, name string) T {
+	t, err := findNamed[T](c.components, name)
+	logLookupError(err)
 	return t
 }
 
+func FindRunner[T IRunner](c *Context) (T, error) {
+	return findTyped[T](c.runners)
+}
+
 func GetRunner[T IRunner](c *Context) T {
-	t, _ := c.GetRunner(func(m IRunner) bool {
-		_, ok := m.(T)
-		return ok
-	}).(T)
+	t, err := FindRunner[T](c)
+	logLookupError(err)
+	return t
+}
+
+func GetRunnerNamed[T IRunner](c *Context, name string) T {
+	t, err := findNamed[T](c.runners, name)
+	logLookupError(err)
 	return t
 }
 
+// logLookupError 未注册时与之前一样静默返回零值，匹配到多个属于配置错误，需要记录
+func logLookupError(err error) {
+	if err != nil && !errors.Is(err, NotRegisteredError) {
+		log.Error("lookup", zap.Error(err))
+	}
+}
+
 func UnmarshalConfig[T any](key string, c *Context) T {
 	var t T
 	newValue := util.NewPtr(t)
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/util"
+)
+
+// IDependency 可选接口，声明初始化前需要先完成初始化的对象。
+// 元素可以是对象的指针类型示例，如 (*UserService)(nil)，
+// 也可以是 reflect.Type，接口类型会匹配所有实现了该接口的对象
+type IDependency interface {
+	DependsOn() []any
+}
+
+func dependencyMatch(dep any, item any) bool {
+	itemType := reflect.TypeOf(item)
+	if itemType == nil {
+		return false
+	}
+	if t, ok := dep.(reflect.Type); ok {
+		return itemType.AssignableTo(t)
+	}
+	return reflect.TypeOf(dep) == itemType
+}
+
+// SortByDependency 按 DependsOn 声明以及 wf:"inject" 字段隐含的依赖关系拓扑排序，被依赖的对象排在前面，
+// 没有依赖关系的对象保持添加顺序；不在 items 中的依赖忽略，存在循环依赖时返回错误
+func SortByDependency[T any](items []T) ([]T, error) {
+	order, err := DependencyOrder(items)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]T, 0, len(items))
+	for _, i := range order {
+		sorted = append(sorted, items[i])
+	}
+	return sorted, nil
+}
+
+// DependencyOrder 与 SortByDependency 的排序相同，返回排序后每个对象在 items 中的下标
+func DependencyOrder[T any](items []T) ([]int, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	sorted := make([]int, 0, len(items))
+	state := make([]int, len(items))
+	path := make([]int, 0, len(items))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			names := make([]string, 0, len(path)+1)
+			for _, k := range path[slices.Index(path, i):] {
+				names = append(names, util.GetStructFullName(items[k]))
+			}
+			names = append(names, util.GetStructFullName(items[i]))
+			return errors.Errorf("dependency cycle detected: %s", strings.Join(names, " -> "))
+		}
+		state[i] = visiting
+		path = append(path, i)
+		deps := injectDependencies(items[i])
+		if dependency, ok := any(items[i]).(IDependency); ok {
+			deps = append(deps, dependency.DependsOn()...)
+		}
+		for _, dep := range deps {
+			for j, item := range items {
+				if j != i && dependencyMatch(dep, item) {
+					if err := visit(j); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		sorted = append(sorted, i)
+		return nil
+	}
+	for i := range items {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type depA struct{}
+
+type depB struct{}
+
+func (b *depB) DependsOn() []any {
+	return []any{(*depA)(nil)}
+}
+
+type depC struct{}
+
+func (c *depC) DependsOn() []any {
+	return []any{reflect.TypeFor[IDependency]()}
+}
+
+type cycleA struct{}
+
+func (a *cycleA) DependsOn() []any {
+	return []any{(*cycleB)(nil)}
+}
+
+type cycleB struct{}
+
+func (b *cycleB) DependsOn() []any {
+	return []any{(*cycleA)(nil)}
+}
+
+func TestSortByDependency(t *testing.T) {
+	a, b, c := &depA{}, &depB{}, &depC{}
+	sorted, err := SortByDependency([]any{c, b, a})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sorted[0] != a || sorted[1] != b || sorted[2] != c {
+		t.Fatalf("unexpected order %v", sorted)
+	}
+}
+
+func TestSortByDependencyCycle(t *testing.T) {
+	_, err := SortByDependency([]any{&cycleA{}, &cycleB{}})
+	if err == nil || !strings.Contains(err.Error(), "core.cycleA -> core.cycleB -> core.cycleA") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
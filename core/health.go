@@ -0,0 +1,207 @@
+package core
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chuccp/go-web-frame/util"
+	"github.com/chuccp/go-web-frame/web"
+	"github.com/sourcegraph/conc/pool"
+)
+
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+type HealthConfig struct {
+	Enable        bool
+	Port          int    `validate:"max=65535"` // 为 0 时使用 web.server 的端口，否则在单独的管理端口上提供
+	Host          string // 单独的管理端口绑定的地址，如 127.0.0.1 只允许本机访问
+	LivenessPath  string // 默认 /healthz
+	ReadinessPath string // 默认 /readyz
+	Timeout       int    // 检查超时时间 单位秒
+}
+
+func (c *HealthConfig) Key() string {
+	return "web.health"
+}
+
+func DefaultHealthConfig() *HealthConfig {
+	return &HealthConfig{
+		Enable:        false,
+		LivenessPath:  "/healthz",
+		ReadinessPath: "/readyz",
+		Timeout:       5,
+	}
+}
+
+type HealthCheckFunc func(ctx context.Context) error
+
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type HealthReport struct {
+	Status string                  `json:"status"`
+	Checks map[string]*CheckResult `json:"checks"`
+}
+
+func (r *HealthReport) IsUp() bool {
+	return r.Status == HealthUp
+}
+
+type healthCheck struct {
+	name     string
+	check    HealthCheckFunc
+	liveness bool
+}
+
+// Health 汇总各个对象的健康检查，/healthz 只执行存活检查，/readyz 执行全部检查，
+// 服务就绪之前以及开始关闭之后 /readyz 返回 503
+type Health struct {
+	config *HealthConfig
+	checks []*healthCheck
+	lock   *sync.RWMutex
+	ready  atomic.Bool
+}
+
+func NewHealth(config *HealthConfig) *Health {
+	return &Health{
+		config: config,
+		checks: make([]*healthCheck, 0),
+		lock:   new(sync.RWMutex),
+	}
+}
+
+func (h *Health) Config() *HealthConfig {
+	return h.config
+}
+
+// AddCheck 添加就绪检查
+func (h *Health) AddCheck(name string, check HealthCheckFunc) {
+	h.addCheck(name, check, false)
+}
+
+// AddLivenessCheck 添加存活检查，存活检查失败说明进程需要重启，同时也会计入就绪检查
+func (h *Health) AddLivenessCheck(name string, check HealthCheckFunc) {
+	h.addCheck(name, check, true)
+}
+
+func (h *Health) addCheck(name string, check HealthCheckFunc, liveness bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.checks = append(h.checks, &healthCheck{name: name, check: check, liveness: liveness})
+}
+
+// Add 将实现了 IHealthCheck 的对象加入就绪检查，以结构体名称作为检查名称
+func (h *Health) Add(items ...any) {
+	for _, item := range items {
+		if check, ok := item.(IHealthCheck); ok {
+			h.AddCheck(util.GetStructFullName(item), check.HealthCheck)
+		}
+	}
+}
+
+func (h *Health) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+func (h *Health) IsReady() bool {
+	return h.ready.Load()
+}
+
+func (h *Health) timeout() time.Duration {
+	if h.config.Timeout <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(h.config.Timeout) * time.Second
+}
+
+// Check 并发执行检查，onlyLiveness 为 true 时只执行存活检查
+func (h *Health) Check(ctx context.Context, onlyLiveness bool) *HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout())
+	defer cancel()
+	h.lock.RLock()
+	checks := append([]*healthCheck(nil), h.checks...)
+	h.lock.RUnlock()
+	report := &HealthReport{Status: HealthUp, Checks: make(map[string]*CheckResult)}
+	results := make([]*CheckResult, len(checks))
+	p := pool.New()
+	for i, check := range checks {
+		if onlyLiveness && !check.liveness {
+			continue
+		}
+		p.Go(func() {
+			start := time.Now()
+			result := &CheckResult{Status: HealthUp}
+			if err := runHealthCheck(ctx, check.check); err != nil {
+				result.Status = HealthDown
+				result.Error = err.Error()
+			}
+			result.Duration = time.Since(start).String()
+			results[i] = result
+		})
+	}
+	p.Wait()
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		name := checks[i].name
+		if _, ok := report.Checks[name]; ok {
+			name = name + "#" + strconv.Itoa(i)
+		}
+		report.Checks[name] = result
+		if result.Status != HealthUp {
+			report.Status = HealthDown
+		}
+	}
+	return report
+}
+
+// runHealthCheck 检查函数不响应 ctx 时也能按超时返回
+func runHealthCheck(ctx context.Context, check HealthCheckFunc) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *Health) liveness(req *web.Request) (any, error) {
+	report := h.Check(req.GinContext().Request.Context(), true)
+	if !report.IsUp() {
+		return web.ServiceUnavailable(report), nil
+	}
+	return web.Data(report), nil
+}
+
+func (h *Health) readiness(req *web.Request) (any, error) {
+	report := h.Check(req.GinContext().Request.Context(), false)
+	if !h.IsReady() {
+		report.Status = HealthDown
+		report.Checks["ready"] = &CheckResult{Status: HealthDown, Error: "the service is not ready", Duration: "0s"}
+	}
+	if !report.IsUp() {
+		return web.ServiceUnavailable(report), nil
+	}
+	return web.Data(report), nil
+}
+
+// Init 作为 IRest 注册 /healthz 和 /readyz
+func (h *Health) Init(ctx *Context) error {
+	ctx.Get(h.config.LivenessPath, h.liveness)
+	ctx.Get(h.config.ReadinessPath, h.readiness)
+	return nil
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/util"
+)
+
+// InjectTag 字段注入标签：
+//
+//	UserService *UserService `wf:"inject"`          // 注入已注册的 service、model、component、runner 或 *core.Context
+//	Primary     *UserService `wf:"inject=primary"`  // 注入按名称注册的对象
+//	Config      *FooConfig   `wf:"config=web.foo"`  // 将配置 web.foo 反序列化后注入
+const InjectTag = "wf"
+
+const (
+	injectValue  = "inject"
+	configPrefix = "config="
+)
+
+var contextType = reflect.TypeOf((*Context)(nil))
+
+// injectFields 返回带 wf 标签的字段，target 不是结构体指针时返回 nil
+func injectFields(target any) (reflect.Value, []reflect.StructField) {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, nil
+	}
+	value = value.Elem()
+	fields := make([]reflect.StructField, 0)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if _, ok := field.Tag.Lookup(InjectTag); ok {
+			fields = append(fields, field)
+		}
+	}
+	return value, fields
+}
+
+// injectDependencies 返回 wf:"inject" 字段的类型，作为初始化排序时的隐式依赖
+func injectDependencies(target any) []any {
+	_, fields := injectFields(target)
+	deps := make([]any, 0, len(fields))
+	for _, field := range fields {
+		tag := strings.TrimSpace(field.Tag.Get(InjectTag))
+		if tag == injectValue || strings.HasPrefix(tag, injectValue+"=") {
+			deps = append(deps, field.Type)
+		}
+	}
+	return deps
+}
+
+// lookup 依次在 component、model、service、runner 中查找可赋值给 t 的对象，
+// name 不为空时按名称查找
+func (c *Context) lookup(t reflect.Type, name string) (any, error) {
+	if t == contextType {
+		return c, nil
+	}
+	finds := []func() (any, error){
+		func() (any, error) { return lookupIn(c.components, t, name) },
+		func() (any, error) { return lookupIn(c.models, t, name) },
+		func() (any, error) { return lookupIn(c.services, t, name) },
+		func() (any, error) { return lookupIn(c.runners, t, name) },
+	}
+	for _, find := range finds {
+		v, err := find()
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, NotRegisteredError) {
+			return nil, err
+		}
+	}
+	if name != "" {
+		return nil, errors.WrapIff(NotRegisteredError, "%s named %s", t, name)
+	}
+	return nil, errors.WrapIff(NotRegisteredError, "no service, model, component or runner of type %s", t)
+}
+
+func lookupIn[T any](r *registry[T], t reflect.Type, name string) (any, error) {
+	if name == "" {
+		return r.find(t)
+	}
+	v, ok := r.getNamed(name)
+	if !ok || !reflect.TypeOf(v).AssignableTo(t) {
+		return nil, NotRegisteredError
+	}
+	return v, nil
+}
+
+// Inject 按 wf 标签为 target 的字段注入依赖，字段必须是导出的，所有无法注入的字段合并为一个错误返回
+func Inject(target any, c *Context) error {
+	value, fields := injectFields(target)
+	errs := make([]error, 0)
+	for _, field := range fields {
+		tag := strings.TrimSpace(field.Tag.Get(InjectTag))
+		name := util.GetStructFullName(target) + "." + field.Name
+		if !field.IsExported() {
+			errs = append(errs, errors.Errorf("inject %s: field is not exported", name))
+			continue
+		}
+		var v reflect.Value
+		switch {
+		case tag == injectValue || strings.HasPrefix(tag, injectValue+"="):
+			dep, err := c.lookup(field.Type, strings.TrimSpace(strings.TrimPrefix(tag[len(injectValue):], "=")))
+			if err != nil {
+				errs = append(errs, errors.WrapIff(err, "inject %s", name))
+				continue
+			}
+			v = reflect.ValueOf(dep)
+		case strings.HasPrefix(tag, configPrefix):
+			key := strings.TrimSpace(strings.TrimPrefix(tag, configPrefix))
+			if !c.GetConfig().HasKey(key) {
+				errs = append(errs, errors.Errorf("inject %s: config key %s not found", name, key))
+				continue
+			}
+			t := field.Type
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			ptr := reflect.New(t)
+			err := c.GetConfig().Unmarshal(key, ptr.Interface())
+			if err != nil {
+				errs = append(errs, errors.WrapIff(err, "inject %s: config key %s", name, key))
+				continue
+			}
+			v = ptr
+			if field.Type.Kind() != reflect.Ptr {
+				v = ptr.Elem()
+			}
+		default:
+			errs = append(errs, errors.Errorf("inject %s: unknown tag %s:%q", name, InjectTag, tag))
+			continue
+		}
+		value.FieldByIndex(field.Index).Set(v)
+	}
+	return errors.Combine(errs...)
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/config"
+)
+
+type injectService struct{}
+
+func (s *injectService) Init(ctx *Context) error {
+	return nil
+}
+
+type injectConfig struct {
+	Name string
+}
+
+type injectTarget struct {
+	Service *injectService `wf:"inject"`
+	Context *Context       `wf:"inject"`
+	Config  *injectConfig  `wf:"config=web.inject"`
+	Value   injectConfig   `wf:"config=web.inject"`
+}
+
+type injectMissing struct {
+	Service *injectService `wf:"inject"`
+	Config  *injectConfig  `wf:"config=web.missing"`
+	context *Context       `wf:"inject"`
+}
+
+func TestInject(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Put("web.inject.name", "frame")
+	ctx := NewContext(cfg, NewSchedule(), DefaultModelGroup())
+	service := &injectService{}
+	ctx.AddService(service)
+	target := &injectTarget{}
+	err := Inject(target, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Service != service || target.Context != ctx {
+		t.Fatal("dependencies were not injected")
+	}
+	if target.Config.Name != "frame" || target.Value.Name != "frame" {
+		t.Fatalf("config was not injected: %+v %+v", target.Config, target.Value)
+	}
+}
+
+func TestInjectMissing(t *testing.T) {
+	ctx := NewContext(config.NewConfig(), NewSchedule(), DefaultModelGroup())
+	err := Inject(&injectMissing{}, ctx)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, field := range []string{"core.injectMissing.Service", "core.injectMissing.Config", "core.injectMissing.context: field is not exported"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Fatalf("error %q does not mention %s", err, field)
+		}
+	}
+}
+
+type injectRepository interface {
+	IService
+	Find() string
+}
+
+type primaryRepository struct{}
+
+func (r *primaryRepository) Init(ctx *Context) error {
+	return nil
+}
+func (r *primaryRepository) Find() string {
+	return "primary"
+}
+
+type replicaRepository struct{}
+
+func (r *replicaRepository) Init(ctx *Context) error {
+	return nil
+}
+func (r *replicaRepository) Find() string {
+	return "replica"
+}
+
+type injectNamed struct {
+	Repository injectRepository `wf:"inject=replica"`
+}
+
+func TestLookupAmbiguous(t *testing.T) {
+	ctx := NewContext(config.NewConfig(), NewSchedule(), DefaultModelGroup())
+	ctx.AddService(&primaryRepository{})
+	if GetService[injectRepository](ctx).Find() != "primary" {
+		t.Fatal("expected the only implementation")
+	}
+	ctx.AddService(&replicaRepository{})
+	_, err := FindService[injectRepository](ctx)
+	if !errors.Is(err, AmbiguousError) {
+		t.Fatalf("expected ambiguous error, got %v", err)
+	}
+	if GetService[*replicaRepository](ctx) == nil {
+		t.Fatal("concrete type lookup failed")
+	}
+}
+
+func TestInjectNamed(t *testing.T) {
+	ctx := NewContext(config.NewConfig(), NewSchedule(), DefaultModelGroup())
+	ctx.AddNamedService("primary", &primaryRepository{})
+	ctx.AddNamedService("replica", &replicaRepository{})
+	if GetServiceNamed[injectRepository](ctx, "primary").Find() != "primary" {
+		t.Fatal("named lookup failed")
+	}
+	target := &injectNamed{}
+	err := Inject(target, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Repository.Find() != "replica" {
+		t.Fatal("named inject failed")
+	}
+}
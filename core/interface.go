@@ -1,6 +1,8 @@
 package core
 
 import (
+	"context"
+
 	config2 "github.com/chuccp/go-web-frame/config"
 	"github.com/chuccp/go-web-frame/db"
 	"github.com/chuccp/go-web-frame/model"
@@ -34,6 +36,37 @@ type IRunner interface {
 	Run() error
 }
 
+// IStarted 可选接口，所有 http 服务端口绑定成功后调用，可用于预热缓存、注册到负载均衡等
+type IStarted interface {
+	OnStarted(ctx context.Context) error
+}
+
+// IStopping 可选接口，开始关闭之前调用，此时 http 服务仍在处理请求
+type IStopping interface {
+	OnStopping(ctx context.Context) error
+}
+
+// IHealthCheck 可选接口，component、model、service、runner 实现后会加入 /readyz 的检查
+type IHealthCheck interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// IReload 可选接口，配置文件变化并校验通过后调用，需要拒绝非法配置时在 Init 中通过 AddValidator 注册校验
+type IReload interface {
+	Reload(config config2.IConfig) error
+}
+
+// IValidateConfig 可选接口，组件在 Init 之前以及配置重新加载时校验自己的配置，
+// 所有错误合并后作为一次启动失败返回
+type IValidateConfig interface {
+	ValidateConfig(config config2.IConfig) error
+}
+
+// IMigrate 可选接口，migrate 命令调用 Migrate 创建或升级表结构，未实现时调用 CreateTable
+type IMigrate interface {
+	Migrate() error
+}
+
 type IModelGroup interface {
 	AddModel(model ...IModel)
 	GetModel() []IModel
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/util"
+)
+
+type LifecycleConfig struct {
+	StartTimeout   int // OnStarted 超时时间 单位秒
+	StopTimeout    int // OnStopping 超时时间 单位秒
+	RestartTimeout int // 平滑重启时等待新进程就绪的超时时间 单位秒
+}
+
+func (c *LifecycleConfig) Key() string {
+	return "web.lifecycle"
+}
+
+const defaultLifecycleTimeout = 30
+
+func DefaultLifecycleConfig() *LifecycleConfig {
+	return &LifecycleConfig{
+		StartTimeout:   defaultLifecycleTimeout,
+		StopTimeout:    defaultLifecycleTimeout,
+		RestartTimeout: defaultLifecycleTimeout,
+	}
+}
+
+func lifecycleTimeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		seconds = defaultLifecycleTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (c *LifecycleConfig) GetStartTimeout() time.Duration {
+	return lifecycleTimeout(c.StartTimeout)
+}
+
+func (c *LifecycleConfig) GetStopTimeout() time.Duration {
+	return lifecycleTimeout(c.StopTimeout)
+}
+
+func (c *LifecycleConfig) GetRestartTimeout() time.Duration {
+	return lifecycleTimeout(c.RestartTimeout)
+}
+
+// OnStarted 按顺序调用实现了 IStarted 的对象，遇到错误立即返回
+func OnStarted(ctx context.Context, items ...any) error {
+	for _, item := range items {
+		if started, ok := item.(IStarted); ok {
+			err := started.OnStarted(ctx)
+			if err != nil {
+				return errors.WrapIff(err, "%s OnStarted", util.GetStructFullName(item))
+			}
+		}
+	}
+	return nil
+}
+
+// OnStopping 按相反顺序调用实现了 IStopping 的对象，所有错误合并返回
+func OnStopping(ctx context.Context, items ...any) error {
+	errs := make([]error, 0)
+	for i := len(items) - 1; i >= 0; i-- {
+		if stopping, ok := items[i].(IStopping); ok {
+			err := stopping.OnStopping(ctx)
+			if err != nil {
+				errs = append(errs, errors.WrapIff(err, "%s OnStopping", util.GetStructFullName(items[i])))
+			}
+		}
+	}
+	return errors.Combine(errs...)
+}
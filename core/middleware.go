@@ -1,5 +1,66 @@
 package core
 
-import "github.com/chuccp/go-web-frame/web"
+import (
+	"github.com/chuccp/go-web-frame/web"
+	"go.uber.org/zap"
+)
 
 type MiddlewareFunc func(request *web.Request, ctx *Context)
+
+// Middleware 返回值与 web.HandlerFunc 一致，返回非 nil 的值（如 web.Message）或错误时中止请求并直接响应；
+// 调用 request.Next() 会执行后续的中间件和处理函数，Next 之后的代码在它们执行完成后运行
+type Middleware func(request *web.Request, ctx *Context) (any, error)
+
+// Middleware 将不返回结果的 MiddlewareFunc 转换为 Middleware
+func (f MiddlewareFunc) Middleware() Middleware {
+	return func(request *web.Request, ctx *Context) (any, error) {
+		f(request, ctx)
+		return nil, nil
+	}
+}
+
+// WithMiddleware 将中间件组合为单个路由的处理函数，放在处理函数之前：
+//
+//	ctx.Get("/user", ctx.WithMiddleware(checkSign, audit), api.user)
+//
+// 中间件按参数顺序执行，并在路由组中间件之后执行
+func (c *Context) WithMiddleware(middleware ...Middleware) web.HandlerFunc {
+	return func(request *web.Request) (any, error) {
+		var value any
+		var err error
+		aborted := false
+		var run func(i int)
+		run = func(i int) {
+			if aborted {
+				return
+			}
+			if i == len(middleware) {
+				request.GinContext().Next()
+				return
+			}
+			nextCalled := false
+			v, e := middleware[i](request.WithNext(func() {
+				if !nextCalled {
+					nextCalled = true
+					run(i + 1)
+				}
+			}), c)
+			if v != nil || e != nil {
+				if !nextCalled {
+					aborted = true
+					value, err = v, e
+					return
+				}
+				request.Logger().Warn("The middleware returned after the response was written", zap.String("path", request.FullPath()), zap.Any("value", v), zap.Error(e))
+			}
+			if !nextCalled {
+				run(i + 1)
+			}
+		}
+		run(0)
+		if aborted {
+			request.Abort()
+		}
+		return value, err
+	}
+}
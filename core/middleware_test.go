@@ -0,0 +1,73 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chuccp/go-web-frame/config"
+	"github.com/chuccp/go-web-frame/web"
+)
+
+type middlewareRest struct {
+	events []string
+}
+
+func (r *middlewareRest) record(name string) Middleware {
+	return func(request *web.Request, ctx *Context) (any, error) {
+		r.events = append(r.events, name+" before")
+		request.Next()
+		r.events = append(r.events, name+" after")
+		return nil, nil
+	}
+}
+
+func (r *middlewareRest) Init(ctx *Context) error {
+	ctx.Get("/order", ctx.WithMiddleware(r.record("a"), r.record("b")), func(request *web.Request) (any, error) {
+		r.events = append(r.events, "handler")
+		return web.Ok(), nil
+	})
+	return nil
+}
+
+func requireToken(request *web.Request, ctx *Context) (any, error) {
+	if request.GinContext().GetHeader("X-Token") == "" {
+		return &web.Message{Code: http.StatusForbidden, Msg: "forbidden"}, nil
+	}
+	return nil, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	serverConfig := web.DefaultServerConfig()
+	rest := &middlewareRest{}
+	group := NewRestGroup(serverConfig).AddRest(rest).Use(requireToken)
+	server := NewServer([]*RestGroup{group}, nil)
+	err := server.Init(NewContext(config.NewConfig(), NewSchedule(), DefaultModelGroup()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := server.getHttpServer(serverConfig)
+
+	recorder := httptest.NewRecorder()
+	httpServer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/order", nil))
+	var message web.Message
+	_ = json.Unmarshal(recorder.Body.Bytes(), &message)
+	if recorder.Code != http.StatusForbidden || message.Msg != "forbidden" || len(rest.events) != 0 {
+		t.Fatalf("expected the request to be rejected: %d %s %v", recorder.Code, recorder.Body.String(), rest.events)
+	}
+
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/order", nil)
+	request.Header.Set("X-Token", "token")
+	httpServer.ServeHTTP(recorder, request)
+	expected := []string{"a before", "b before", "handler", "b after", "a after"}
+	if recorder.Code != http.StatusOK || len(rest.events) != len(expected) {
+		t.Fatalf("unexpected result %d %v", recorder.Code, rest.events)
+	}
+	for i, event := range expected {
+		if rest.events[i] != event {
+			t.Fatalf("unexpected order %v", rest.events)
+		}
+	}
+}
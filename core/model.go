@@ -1,6 +1,8 @@
 package core
 
 import (
+	"context"
+
 	"emperror.dev/errors"
 	"github.com/chuccp/go-web-frame/db"
 	"github.com/chuccp/go-web-frame/log"
@@ -37,6 +39,14 @@ func (m *ModelGroup) SwitchDB(db *db.DB, context *Context) error {
 	return nil
 }
 
+// HealthCheck 检查数据库连接，未配置数据库时不检查
+func (m *ModelGroup) HealthCheck(ctx context.Context) error {
+	if m.db == nil {
+		return nil
+	}
+	return m.db.Ping(ctx)
+}
+
 func (m *ModelGroup) Name() string {
 	return m.name
 }
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+)
+
+var NotRegisteredError = errors.NewPlain("not registered")
+
+var AmbiguousError = errors.NewPlain("ambiguous")
+
+// registry 按具体类型索引已注册的对象，同一具体类型重复注册时覆盖之前的对象；
+// 按类型（包括接口类型）查找的结果会被缓存，注册新对象时清空缓存
+type registry[T any] struct {
+	lock  *sync.RWMutex
+	items []T
+	types map[reflect.Type]int
+	named map[string]T
+	cache map[reflect.Type][]T
+}
+
+func newRegistry[T any]() *registry[T] {
+	return &registry[T]{
+		lock:  new(sync.RWMutex),
+		items: make([]T, 0),
+		types: make(map[reflect.Type]int),
+		named: make(map[string]T),
+		cache: make(map[reflect.Type][]T),
+	}
+}
+
+func (r *registry[T]) add(items ...T) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, item := range items {
+		t := reflect.TypeOf(item)
+		if i, ok := r.types[t]; ok {
+			r.items[i] = item
+			continue
+		}
+		r.types[t] = len(r.items)
+		r.items = append(r.items, item)
+	}
+	clear(r.cache)
+}
+
+func (r *registry[T]) addNamed(name string, item T) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.named[name] = item
+}
+
+// all 按注册顺序返回所有未命名的对象
+func (r *registry[T]) all() []T {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return append([]T(nil), r.items...)
+}
+
+func (r *registry[T]) getNamed(name string) (T, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	item, ok := r.named[name]
+	return item, ok
+}
+
+func (r *registry[T]) matches(t reflect.Type) []T {
+	r.lock.RLock()
+	m, ok := r.cache[t]
+	r.lock.RUnlock()
+	if ok {
+		return m
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if i, ok := r.types[t]; ok {
+		m = []T{r.items[i]}
+	} else {
+		m = make([]T, 0, 1)
+		for _, item := range r.items {
+			if reflect.TypeOf(item).AssignableTo(t) {
+				m = append(m, item)
+			}
+		}
+	}
+	r.cache[t] = m
+	return m
+}
+
+// find 查找可赋值给 t 的唯一对象，未找到返回 NotRegisteredError，匹配到多个返回 AmbiguousError
+func (r *registry[T]) find(t reflect.Type) (T, error) {
+	var zero T
+	m := r.matches(t)
+	switch len(m) {
+	case 0:
+		return zero, errors.WrapIff(NotRegisteredError, "%s", t)
+	case 1:
+		return m[0], nil
+	}
+	names := make([]string, len(m))
+	for i, item := range m {
+		names[i] = reflect.TypeOf(item).String()
+	}
+	return zero, errors.WrapIff(AmbiguousError, "%s matches %s", t, strings.Join(names, ", "))
+}
+
+// findTyped 按类型参数 T 查找，供泛型的 GetXxx 函数使用
+func findTyped[T any, I any](r *registry[I]) (T, error) {
+	var zero T
+	v, err := r.find(reflect.TypeFor[T]())
+	if err != nil {
+		return zero, err
+	}
+	return any(v).(T), nil
+}
+
+func findNamed[T any, I any](r *registry[I], name string) (T, error) {
+	var zero T
+	v, ok := r.getNamed(name)
+	if !ok {
+		return zero, errors.WrapIff(NotRegisteredError, "%s named %s", reflect.TypeFor[T](), name)
+	}
+	t, ok := any(v).(T)
+	if !ok {
+		return zero, errors.Errorf("%s named %s is %s", reflect.TypeFor[T](), name, reflect.TypeOf(v))
+	}
+	return t, nil
+}
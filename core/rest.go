@@ -1,16 +1,23 @@
 package core
 
 import (
+	"emperror.dev/errors"
 	"github.com/chuccp/go-web-frame/web"
 )
 
 type RestGroup struct {
-	rests          []IRest
-	port           int
-	name           string
-	digestAuth     *web.DigestAuth
-	middlewareFunc []MiddlewareFunc
-	serverConfig   *web.ServerConfig
+	rests        []IRest
+	port         int
+	name         string
+	digestAuth   *web.DigestAuth
+	cors         *web.CorsConfig
+	security     *web.SecurityConfig
+	csrf         *web.CsrfConfig
+	middleware   []Middleware
+	serverConfig *web.ServerConfig
+	prefix       string
+	parent       *RestGroup
+	children     []*RestGroup
 }
 
 func (rg *RestGroup) DigestAuth() *web.DigestAuth {
@@ -19,13 +26,71 @@ func (rg *RestGroup) DigestAuth() *web.DigestAuth {
 func (rg *RestGroup) Port() int {
 	return rg.port
 }
+
+// Prefix 设置路由前缀，组内 rest 注册的路由、中间件和认证都限定在该前缀下
+func (rg *RestGroup) Prefix(prefix string) *RestGroup {
+	rg.prefix = prefix
+	return rg
+}
+
+// BasePath 返回包含所有上级路由组前缀的完整前缀
+func (rg *RestGroup) BasePath() string {
+	if rg.parent == nil {
+		return joinPaths("/", rg.prefix)
+	}
+	return joinPaths(rg.parent.BasePath(), rg.prefix)
+}
+
+// Group 创建嵌套的子路由组，子路由组继承上级的端口、前缀、中间件和认证，
+// 子路由组的中间件在上级中间件之后执行
+func (rg *RestGroup) Group(prefix string) *RestGroup {
+	child := &RestGroup{
+		rests:        make([]IRest, 0),
+		port:         rg.port,
+		serverConfig: rg.serverConfig,
+		prefix:       prefix,
+		parent:       rg,
+	}
+	rg.children = append(rg.children, child)
+	return child
+}
+
 func (rg *RestGroup) AddRest(rest ...IRest) *RestGroup {
 	rg.rests = append(rg.rests, rest...)
 	return rg
 }
 
 func (rg *RestGroup) AddMiddlewares(middlewareFunc ...MiddlewareFunc) *RestGroup {
-	rg.middlewareFunc = append(rg.middlewareFunc, middlewareFunc...)
+	for _, f := range middlewareFunc {
+		rg.middleware = append(rg.middleware, f.Middleware())
+	}
+	return rg
+}
+
+// Use 添加可中止请求的中间件，与 AddMiddlewares 添加的中间件按添加顺序执行
+func (rg *RestGroup) Use(middleware ...Middleware) *RestGroup {
+	rg.middleware = append(rg.middleware, middleware...)
+	return rg
+}
+
+// Cors 设置当前路由组前缀下的跨域配置，覆盖端口的 cors 配置，未设置的子路由组沿用上级的配置
+func (rg *RestGroup) Cors(config *web.CorsConfig) *RestGroup {
+	rg.cors = config
+	return rg
+}
+
+// Security 设置当前路由组前缀下的安全响应头，覆盖端口的 security 配置，未设置的子路由组沿用上级的配置
+func (rg *RestGroup) Security(config *web.SecurityConfig) *RestGroup {
+	rg.security = config
+	return rg
+}
+
+// Csrf 为当前路由组及子路由组启用 CSRF 防护，在路由组的其他中间件之前执行，config 为 nil 时使用默认配置
+func (rg *RestGroup) Csrf(config *web.CsrfConfig) *RestGroup {
+	if config == nil {
+		config = &web.CsrfConfig{}
+	}
+	rg.csrf = config
 	return rg
 }
 
@@ -34,6 +99,15 @@ func (rg *RestGroup) Merge(restGroup *RestGroup) *RestGroup {
 	if rg.digestAuth == nil {
 		rg.digestAuth = restGroup.digestAuth
 	}
+	if rg.cors == nil {
+		rg.cors = restGroup.cors
+	}
+	if rg.security == nil {
+		rg.security = restGroup.security
+	}
+	if rg.csrf == nil {
+		rg.csrf = restGroup.csrf
+	}
 	if rg.port == 0 {
 		rg.port = restGroup.port
 	}
@@ -42,9 +116,53 @@ func (rg *RestGroup) Merge(restGroup *RestGroup) *RestGroup {
 			rg.serverConfig = restGroup.serverConfig
 		}
 	}
-	rg.middlewareFunc = append(rg.middlewareFunc, restGroup.middlewareFunc...)
+	rg.middleware = append(rg.middleware, restGroup.middleware...)
+	rg.children = append(rg.children, restGroup.children...)
 	return rg
 }
+
+// init 在 context 下创建当前路由组的 Context，依次初始化组内的 rest 和子路由组
+func (rg *RestGroup) init(context *Context) error {
+	restContext := context.Group(rg.prefix, rg.digestAuth)
+	if rg.csrf != nil {
+		restContext.UseMiddleware(func(request *web.Request, ctx *Context) (any, error) {
+			return rg.csrf.Handle(request)
+		})
+	}
+	restContext.UseMiddleware(rg.middleware...)
+	if rg.cors != nil {
+		restContext.Cors(rg.cors)
+	}
+	if rg.security != nil {
+		restContext.Security(rg.security)
+	}
+	for _, rest := range rg.rests {
+		err := Inject(rest, restContext)
+		if err != nil {
+			return err
+		}
+		err = rest.Init(restContext)
+		if err != nil {
+			return errors.WithStackIf(err)
+		}
+	}
+	for _, child := range rg.children {
+		err := child.init(restContext)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allRests 返回当前路由组及所有子路由组的 rest
+func (rg *RestGroup) allRests() []IRest {
+	rests := append([]IRest(nil), rg.rests...)
+	for _, child := range rg.children {
+		rests = append(rests, child.allRests()...)
+	}
+	return rests
+}
 func (rg *RestGroup) Authentication(authentication web.Authentication) *RestGroup {
 	if rg.digestAuth == nil {
 		rg.digestAuth = web.NewDigestAuth(authentication)
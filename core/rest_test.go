@@ -0,0 +1,121 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chuccp/go-web-frame/config"
+	"github.com/chuccp/go-web-frame/web"
+)
+
+type pathRest struct {
+	path string
+	body string
+}
+
+func (r *pathRest) Init(ctx *Context) error {
+	ctx.Get(r.path, func(req *web.Request) (any, error) {
+		return r.body + strings.Join(req.GinContext().Request.Header.Values("X-Trace"), ","), nil
+	})
+	return nil
+}
+
+func traceMiddleware(name string) MiddlewareFunc {
+	return func(req *web.Request, ctx *Context) {
+		req.GinContext().Request.Header.Add("X-Trace", name)
+	}
+}
+
+func TestRestGroupPrefix(t *testing.T) {
+	serverConfig := web.DefaultServerConfig()
+	teamA := NewRestGroup(serverConfig).Prefix("/api/a").AddRest(&pathRest{path: "/user", body: "a"})
+	teamA.AddMiddlewares(traceMiddleware("a"))
+	admin := teamA.Group("/admin").AddRest(&pathRest{path: "/user", body: "admin"})
+	admin.AddMiddlewares(traceMiddleware("admin"))
+	teamB := NewRestGroup(serverConfig).Prefix("/api/b").AddRest(&pathRest{path: "/user", body: "b"})
+	server := NewServer([]*RestGroup{teamA, teamB}, nil)
+	err := server.Init(NewContext(config.NewConfig(), NewSchedule(), DefaultModelGroup()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if admin.BasePath() != "/api/a/admin" {
+		t.Fatalf("unexpected base path %s", admin.BasePath())
+	}
+	httpServer := server.getHttpServer(serverConfig)
+	for path, expected := range map[string]string{
+		"/api/a/user":       "aa",
+		"/api/a/admin/user": "admina,admin",
+		"/api/b/user":       "b",
+	} {
+		recorder := httptest.NewRecorder()
+		httpServer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code != http.StatusOK || recorder.Body.String() != expected {
+			t.Fatalf("%s: %d %q, expected %q", path, recorder.Code, recorder.Body.String(), expected)
+		}
+	}
+}
+
+type csrfRest struct{}
+
+func (r *csrfRest) Init(ctx *Context) error {
+	ctx.Get("/form", func(req *web.Request) (any, error) {
+		return req.CsrfToken(), nil
+	})
+	ctx.Post("/save", func(req *web.Request) (any, error) {
+		return web.Ok(), nil
+	})
+	ctx.Post("/webhook/:id", func(req *web.Request) (any, error) {
+		return web.Ok(), nil
+	})
+	return nil
+}
+
+func TestRestGroupCsrf(t *testing.T) {
+	serverConfig := web.DefaultServerConfig()
+	group := NewRestGroup(serverConfig).Prefix("/app").AddRest(&csrfRest{}).Csrf(&web.CsrfConfig{Exempt: []string{"/app/webhook/:id"}})
+	server := NewServer([]*RestGroup{group}, nil)
+	err := server.Init(NewContext(config.NewConfig(), NewSchedule(), DefaultModelGroup()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := server.getHttpServer(serverConfig)
+	serve := func(request *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		httpServer.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	form := serve(httptest.NewRequest(http.MethodGet, "/app/form", nil))
+	token := form.Body.String()
+	cookies := form.Result().Cookies()
+	if len(token) == 0 || len(cookies) != 1 || cookies[0].Value != token || cookies[0].HttpOnly || form.Header().Get(web.DefaultCsrfHeaderName) != token {
+		t.Fatalf("unexpected token %q cookies %v", token, cookies)
+	}
+
+	post := func(header string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/app/save", strings.NewReader(body))
+		request.AddCookie(cookies[0])
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if len(header) > 0 {
+			request.Header.Set(web.DefaultCsrfHeaderName, header)
+		}
+		return serve(request)
+	}
+	if w := post("", ""); w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "invalid csrf token") {
+		t.Fatalf("missing token: %d %s", w.Code, w.Body.String())
+	}
+	if w := post("other", ""); w.Code != http.StatusForbidden {
+		t.Fatalf("wrong token: %d", w.Code)
+	}
+	if w := post(token, ""); w.Code != http.StatusOK {
+		t.Fatalf("header token: %d %s", w.Code, w.Body.String())
+	}
+	if w := post("", "csrf_token="+token); w.Code != http.StatusOK {
+		t.Fatalf("form token: %d %s", w.Code, w.Body.String())
+	}
+	if w := serve(httptest.NewRequest(http.MethodPost, "/app/webhook/1", nil)); w.Code != http.StatusOK {
+		t.Fatalf("exempt route: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -1,5 +1,13 @@
 package core
 
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
 type RouteInfo []string
 
 type RouteTree map[string]RouteInfo
@@ -18,3 +26,47 @@ func (rt RouteTree) Has(method, path string) bool {
 	}
 	return false
 }
+
+// Route 已注册的路由，Handler 为处理函数名，Auth 表示需要登录
+type Route struct {
+	Port    int
+	Method  string
+	Path    string
+	Handler string
+	Auth    bool
+}
+
+// routeTable 所有 Context 共享，记录各端口注册的路由
+type routeTable struct {
+	lock   sync.Mutex
+	routes []Route
+}
+
+func (t *routeTable) add(route Route) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.routes = append(t.routes, route)
+}
+
+// all 按端口、路径、方法排序
+func (t *routeTable) all() []Route {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	routes := slices.Clone(t.routes)
+	slices.SortStableFunc(routes, func(a, b Route) int {
+		return cmp.Or(cmp.Compare(a.Port, b.Port), strings.Compare(a.Path, b.Path), strings.Compare(a.Method, b.Method))
+	})
+	return routes
+}
+
+// joinPaths 与 gin 拼接路由组路径的规则一致，保留 relativePath 末尾的 /
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
@@ -1,15 +1,19 @@
 package core
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"emperror.dev/errors"
 
 	config2 "github.com/chuccp/go-web-frame/config"
 	"github.com/chuccp/go-web-frame/log"
+	"github.com/google/uuid"
 	"github.com/robfig/cron/v3"
 	"github.com/sourcegraph/conc/panics"
+	"go.uber.org/zap"
 )
 
 type Info struct {
@@ -33,6 +37,8 @@ type Schedule struct {
 	lock      *sync.RWMutex
 	config    *ScheduleConfig
 	idInfoMap map[uint]*Info
+	running   atomic.Bool
+	enable    atomic.Bool
 }
 
 func NewSchedule() *Schedule {
@@ -44,15 +50,32 @@ func NewSchedule() *Schedule {
 		config:    &ScheduleConfig{Enable: false},
 	}
 }
+
+// job 每次执行时生成请求 ID，cmd 中通过 log.Ctx(ctx) 输出的日志和使用 ctx 的查询都包含该 ID，panic 时记录日志
+func (c *Schedule) job(spec string, cmd func(ctx context.Context)) func() {
+	return func() {
+		ctx := log.WithRequestID(context.Background(), uuid.NewString())
+		var catcher panics.Catcher
+		catcher.Try(func() { cmd(ctx) })
+		if err := catcher.Recovered().AsError(); err != nil {
+			log.Ctx(ctx).Errors(spec, err)
+		}
+	}
+}
+
+// AddFuncContext 与 AddFunc 相同，cmd 接收带有本次执行请求 ID 的 ctx
+func (c *Schedule) AddFuncContext(spec string, cmd func(ctx context.Context)) (cron.EntryID, error) {
+	if !c.enable.Load() {
+		return 0, errors.New("schedule is not enable")
+	}
+	return c.cron.AddFunc(spec, c.job(spec, cmd))
+}
+
 func (c *Schedule) AddFunc(spec string, cmd func()) (cron.EntryID, error) {
-	if !c.config.Enable {
+	if !c.enable.Load() {
 		return 0, errors.New("schedule is not enable")
 	}
-	return c.cron.AddFunc(spec, func() {
-		var catcher panics.Catcher
-		catcher.Try(cmd)
-		log.Errors(spec, catcher.Recovered().AsError())
-	})
+	return c.cron.AddFunc(spec, c.job(spec, func(context.Context) { cmd() }))
 }
 func (c *Schedule) StopKeyFunc(key string) {
 	c.lock.Lock()
@@ -63,16 +86,12 @@ func (c *Schedule) StopKeyFunc(key string) {
 	}
 }
 func (c *Schedule) ReplaceKeyFunc(key string, spec string, cmd func()) (cron.EntryID, error) {
-	if !c.config.Enable {
+	if !c.enable.Load() {
 		return 0, errors.New("schedule is not enable")
 	}
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	v, err := c.cron.AddFunc(spec, func() {
-		var catcher panics.Catcher
-		catcher.Try(cmd)
-		log.Errors(spec, catcher.Recovered().AsError())
-	})
+	v, err := c.cron.AddFunc(spec, c.job(spec, func(context.Context) { cmd() }))
 	if err != nil {
 		return 0, err
 	}
@@ -89,7 +108,7 @@ func (c *Schedule) ReplaceKeyFunc(key string, spec string, cmd func()) (cron.Ent
 	return v, err
 }
 func (c *Schedule) AddKeyFunc(key string, spec string, cmd func()) (cron.EntryID, bool, error) {
-	if !c.config.Enable {
+	if !c.enable.Load() {
 		return 0, false, errors.New("schedule is not enable")
 	}
 	c.lock.Lock()
@@ -98,11 +117,7 @@ func (c *Schedule) AddKeyFunc(key string, spec string, cmd func()) (cron.EntryID
 	if ok {
 		return 0, ok, nil
 	}
-	v, err := c.cron.AddFunc(spec, func() {
-		var catcher panics.Catcher
-		catcher.Try(cmd)
-		log.Errors(spec, catcher.Recovered().AsError())
-	})
+	v, err := c.cron.AddFunc(spec, c.job(spec, func(context.Context) { cmd() }))
 	if err != nil {
 		return 0, ok, err
 	}
@@ -123,7 +138,7 @@ func (c *Schedule) StopIdFunc(id uint) {
 	}
 }
 func (c *Schedule) AddIdOrReplaceKeyFunc(id uint, key string, spec string, cmd func()) (cron.EntryID, bool, error) {
-	if !c.config.Enable {
+	if !c.enable.Load() {
 		return 0, false, errors.New("schedule is not enable")
 	}
 	c.lock.Lock()
@@ -134,11 +149,7 @@ func (c *Schedule) AddIdOrReplaceKeyFunc(id uint, key string, spec string, cmd f
 			return info.entryID, ok, nil
 		}
 	}
-	v, err := c.cron.AddFunc(spec, func() {
-		var catcher panics.Catcher
-		catcher.Try(cmd)
-		log.Errors(spec, catcher.Recovered().AsError())
-	})
+	v, err := c.cron.AddFunc(spec, c.job(spec, func(context.Context) { cmd() }))
 	if err != nil {
 		return 0, ok, err
 	}
@@ -158,22 +169,66 @@ func (c *Schedule) Init(config config2.IConfig) error {
 	if err != nil {
 		return errors.WithStackIf(err)
 	}
+	c.enable.Store(c.config.Enable)
 	if c.config.Enable {
 		return c.Run()
 
 	}
 	return nil
 }
+
+// Reload 配置变化后启用或停用定时任务，停用时不等待正在执行的任务
+func (c *Schedule) Reload(config config2.IConfig) error {
+	scheduleConfig := &ScheduleConfig{}
+	err := config.Unmarshal(scheduleConfig.Key(), scheduleConfig)
+	if err != nil {
+		return errors.WithStackIf(err)
+	}
+	if c.enable.Swap(scheduleConfig.Enable) == scheduleConfig.Enable {
+		return nil
+	}
+	log.Info("schedule enable changed", zap.Bool("enable", scheduleConfig.Enable))
+	if scheduleConfig.Enable {
+		return c.Run()
+	}
+	c.running.Store(false)
+	c.cron.Stop()
+	return nil
+}
 func (c *Schedule) Name() string {
 	return "schedule"
 }
 
 func (c *Schedule) Run() error {
 	c.cron.Start()
+	c.running.Store(true)
 	return nil
 }
+
+// HealthCheck 定时任务已启用但未运行时返回错误
+func (c *Schedule) HealthCheck(ctx context.Context) error {
+	if c.enable.Load() && !c.running.Load() {
+		return errors.New("schedule is not running")
+	}
+	return nil
+}
+
+// Shutdown 停止调度新的任务，并等待正在执行的任务结束，ctx 超时则不再等待
+func (c *Schedule) Shutdown(ctx context.Context) error {
+	if !c.enable.Load() {
+		return nil
+	}
+	c.running.Store(false)
+	select {
+	case <-c.cron.Stop().Done():
+		return nil
+	case <-ctx.Done():
+		return errors.WithStackIf(ctx.Err())
+	}
+}
 func (c *Schedule) Destroy() error {
-	if c.config.Enable {
+	if c.enable.Load() {
+		c.running.Store(false)
 		c.cron.Stop()
 	}
 	return nil
@@ -1,7 +1,12 @@
 package core
 
 import (
+	"context"
+	"maps"
+	"os"
+	"slices"
 	"sync"
+	"time"
 
 	"emperror.dev/errors"
 	"github.com/chuccp/go-web-frame/web"
@@ -14,6 +19,8 @@ type Server struct {
 	httpServers map[int]*web.HttpServer
 	lock        *sync.RWMutex
 	runners     []IRunner
+	runnerErrs  []error
+	panicHooks  []web.PanicHook
 }
 
 func (server *Server) getHttpServer(serverConfig *web.ServerConfig) *web.HttpServer {
@@ -23,55 +30,176 @@ func (server *Server) getHttpServer(serverConfig *web.ServerConfig) *web.HttpSer
 		return httpServer
 	}
 	httpServer := web.NewHttpServer(serverConfig, server.certManager)
+	httpServer.OnPanic(server.panicHooks...)
 	server.httpServers[serverConfig.Port] = httpServer
 	return httpServer
 }
+
+// OnPanic 添加所有端口处理函数 panic 后调用的 hook，需要在 Init 之前添加
+func (server *Server) OnPanic(hook ...web.PanicHook) {
+	server.panicHooks = append(server.panicHooks, hook...)
+}
+
+// Init 初始化所有路由组中的 rest，runner 作为 IService 与 service 一起按依赖顺序初始化
 func (server *Server) Init(context *Context) error {
-	for _, runner := range server.runners {
-		err := runner.Init(context)
-		if err != nil {
-			return errors.WithStackIf(err)
-		}
-	}
 	for _, restGroup := range server.restGroups {
 		serverConfig := restGroup.serverConfig
 		httpServer := server.getHttpServer(serverConfig)
-		restContext := context.Copy(restGroup.digestAuth, httpServer)
-		restContext.Use(restGroup.middlewareFunc...)
-		for _, rest := range restGroup.rests {
-			err := rest.Init(restContext)
-			if err != nil {
-				return errors.WithStackIf(err)
+		err := restGroup.init(context.Copy(restGroup.digestAuth, httpServer))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HttpServer 返回端口对应的 http 服务，不存在时返回 nil
+func (server *Server) HttpServer(port int) *web.HttpServer {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+	return server.httpServers[port]
+}
+
+// Listen 绑定所有 http 服务的端口，任一端口绑定失败时关闭已绑定的端口并返回错误
+func (server *Server) Listen() error {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+	for _, httpServer := range server.httpServers {
+		err := httpServer.Listen()
+		if err != nil {
+			for _, s := range server.httpServers {
+				_ = s.Close()
 			}
+			return err
 		}
 	}
 	return nil
 }
+
+// ListenerFiles 按端口顺序返回所有 http 服务的监听地址和文件描述符副本，用于平滑重启时传递给新进程，
+// 调用方负责关闭返回的文件
+func (server *Server) ListenerFiles() ([]string, []*os.File, error) {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+	ports := slices.Sorted(maps.Keys(server.httpServers))
+	addrs := make([]string, 0, len(ports))
+	files := make([]*os.File, 0, len(ports))
+	for _, port := range ports {
+		httpServer := server.httpServers[port]
+		file, err := httpServer.ListenerFile()
+		if err != nil {
+			for _, f := range files {
+				_ = f.Close()
+			}
+			return nil, nil, err
+		}
+		addrs = append(addrs, httpServer.Addr())
+		files = append(files, file)
+	}
+	return addrs, files, nil
+}
+
+// SetUnlinkOnClose 设置所有监听 unix socket 的 http 服务关闭后是否删除 socket 文件
+func (server *Server) SetUnlinkOnClose(unlink bool) {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+	for _, httpServer := range server.httpServers {
+		httpServer.SetUnlinkOnClose(unlink)
+	}
+}
+
+// Rests 返回所有 RestGroup 中的 rest
+func (server *Server) Rests() []IRest {
+	rests := make([]IRest, 0)
+	for _, restGroup := range server.restGroups {
+		rests = append(rests, restGroup.allRests()...)
+	}
+	return rests
+}
+
+// Run 启动所有 http 服务和 runner，阻塞直到全部退出；未调用 Listen 时 http 服务会先绑定端口
 func (server *Server) Run() error {
 	var wg = pool.New()
 	wg.WithMaxGoroutines(len(server.httpServers) + len(server.runners))
 	errorsPool := wg.WithErrors()
 	for _, httpServer := range server.httpServers {
 		errorsPool.Go(func() error {
-			return errors.WithStackIf(httpServer.Run())
+			return errors.WithStackIf(httpServer.Serve())
 		})
 	}
-	for _, runner := range server.runners {
+	for i, runner := range server.runners {
 		errorsPool.Go(func() error {
-			return errors.WithStackIf(runner.Run())
+			err := errors.WithStackIf(runner.Run())
+			if err != nil {
+				server.lock.Lock()
+				server.runnerErrs[i] = err
+				server.lock.Unlock()
+			}
+			return err
 		})
 	}
 	server.certManager.Start()
 	return errorsPool.Wait()
 }
-func (server *Server) Destroy() error {
-	errs := make([]error, 0)
+
+// Shutdown 并发关闭所有 http 服务，每个服务按各自配置的 shutdownTimeout 等待进行中的请求完成
+func (server *Server) Shutdown(ctx context.Context) error {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+	errorsPool := pool.New().WithErrors()
 	for _, httpServer := range server.httpServers {
-		err := httpServer.Close()
-		errs = append(errs, err)
+		errorsPool.Go(func() error {
+			timeoutCtx, cancel := context.WithTimeout(ctx, httpServer.ShutdownTimeout())
+			defer cancel()
+			return httpServer.Shutdown(timeoutCtx)
+		})
 	}
-	for _, runner := range server.runners {
-		err := runner.Destroy()
+	return errorsPool.Wait()
+}
+
+// ShutdownTimeout 返回所有 http 服务中最长的关闭等待时间
+func (server *Server) ShutdownTimeout() time.Duration {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+	var timeout time.Duration
+	for _, httpServer := range server.httpServers {
+		if t := httpServer.ShutdownTimeout(); t > timeout {
+			timeout = t
+		}
+	}
+	if timeout == 0 {
+		return web.DefaultShutdownTimeout * time.Second
+	}
+	return timeout
+}
+
+// RunnerHealthCheck 返回 Runners 中下标为 index 的 runner 的健康检查：
+// Run 返回过错误时不健康，否则调用 runner 实现的 HealthCheck
+func (server *Server) RunnerHealthCheck(index int) HealthCheckFunc {
+	return func(ctx context.Context) error {
+		server.lock.RLock()
+		err := server.runnerErrs[index]
+		server.lock.RUnlock()
+		if err != nil {
+			return err
+		}
+		if healthCheck, ok := server.runners[index].(IHealthCheck); ok {
+			return healthCheck.HealthCheck(ctx)
+		}
+		return nil
+	}
+}
+
+// Runners 返回按初始化顺序排列的 runner
+func (server *Server) Runners() []IRunner {
+	return server.runners
+}
+
+// Destroy 按初始化的相反顺序销毁所有 runner，http 服务需先通过 Shutdown 关闭
+func (server *Server) Destroy() error {
+	errs := make([]error, 0)
+	for i := len(server.runners) - 1; i >= 0; i-- {
+		err := server.runners[i].Destroy()
 		errs = append(errs, err)
 	}
 	return errors.Combine(errs...)
@@ -83,5 +211,6 @@ func NewServer(restGroups []*RestGroup, runners []IRunner) *Server {
 		httpServers: make(map[int]*web.HttpServer),
 		lock:        new(sync.RWMutex),
 		runners:     runners,
+		runnerErrs:  make([]error, len(runners)),
 	}
 }
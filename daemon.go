@@ -1,14 +1,31 @@
 package wf
 
 import (
-	"flag"
 	"fmt"
+	"maps"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
 
+	"emperror.dev/errors"
 	"github.com/chuccp/go-web-frame/log"
 	"github.com/kardianos/service"
 )
 
+// Daemon 命令的退出码，status 的退出码参照 LSB：运行中 0，已停止 3，未安装或未知 4
+const (
+	ExitOK           = 0
+	ExitFailure      = 1
+	ExitUsage        = 2
+	ExitStopped      = 3
+	ExitNotInstalled = 4
+)
+
+const daemonUsage = `usage: <install | uninstall | start | stop | restart | status | run> [--key=value ...]
+  -stop  deprecated, same as stop`
+
 type AppService interface {
 	Start() error
 	Close() error
@@ -16,48 +33,322 @@ type AppService interface {
 
 type AppDaemon struct {
 	appService AppService
+	logger     service.Logger
+	exited     chan struct{}
+	startErr   error // exited 关闭后才能读取
+}
+
+// ReadyService 可选接口，Daemon 启动时等待 Ready 通道关闭后才向服务管理器报告启动成功
+type ReadyService interface {
+	Ready() <-chan struct{}
 }
 
 func (a *AppDaemon) Start(s service.Service) error {
+	startErr := make(chan error, 1)
 	go func() {
+		defer close(a.exited)
 		err := a.appService.Start()
 		if err != nil {
-			log.Errors("Failed to start the Daemon service", err)
+			a.logger.Errorf("Failed to start the Daemon service: %+v", err)
+			a.startErr = err
 		}
+		startErr <- err
 	}()
+	if readyService, ok := a.appService.(ReadyService); ok {
+		select {
+		case <-readyService.Ready():
+			a.logger.Info("The Daemon service has started")
+		case err := <-startErr:
+			return err
+		}
+	}
 	return nil
 }
 
+// wait 等待停止信号，平滑重启后 appService.Start 返回时进程也随之退出
+func (a *AppDaemon) wait() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	select {
+	case <-signals:
+	case <-a.exited:
+	}
+}
+
 func (a *AppDaemon) Stop(s service.Service) error {
-	return a.appService.Close()
+	err := a.appService.Close()
+	if err != nil {
+		a.logger.Errorf("Failed to stop the Daemon service: %+v", err)
+	}
+	return err
+}
+
+// DaemonConfig 服务管理器中的配置，非空的配置项覆盖 Daemon 传入的 service.Config，Env 格式为 KEY=VALUE
+type DaemonConfig struct {
+	Name             string
+	DisplayName      string
+	Description      string
+	Arguments        []string
+	WorkingDirectory string
+	UserName         string
+	Env              []string
+}
+
+func (d *DaemonConfig) Key() string {
+	return "web.daemon"
 }
 
-func RunDaemon(appService AppService, svcConfig *service.Config) {
-	// 解析启停参数
-	stopFlag := flag.Bool("stop", false, "停止服务")
-	flag.Parse()
+func (d *DaemonConfig) Validate() error {
+	for _, env := range d.Env {
+		if key, _, ok := strings.Cut(env, "="); !ok || len(key) == 0 {
+			return errors.Errorf("env %q must be KEY=VALUE", env)
+		}
+	}
+	return nil
+}
+
+// systemdScript 在 kardianos/service 默认模板的基础上增加 NotifyAccess=main，
+// 平滑重启后新进程通过 MAINPID 成为主进程，旧进程退出时 systemd 不会停止服务
+const systemdScript = `[Unit]
+Description={{.Description}}
+ConditionFileIsExecutable={{.Path|cmdEscape}}
+{{range $i, $dep := .Dependencies}} 
+{{$dep}} {{end}}
+
+[Service]
+StartLimitInterval=5
+StartLimitBurst=10
+ExecStart={{.Path|cmdEscape}}{{range .Arguments}} {{.|cmd}}{{end}}
+NotifyAccess=main
+{{if .ChRoot}}RootDirectory={{.ChRoot|cmd}}{{end}}
+{{if .WorkingDirectory}}WorkingDirectory={{.WorkingDirectory|cmdEscape}}{{end}}
+{{if .UserName}}User={{.UserName}}{{end}}
+{{if .ReloadSignal}}ExecReload=/bin/kill -{{.ReloadSignal}} "$MAINPID"{{end}}
+{{if .PIDFile}}PIDFile={{.PIDFile|cmd}}{{end}}
+{{if and .LogOutput .HasOutputFileSupport -}}
+StandardOutput=file:{{.LogDirectory}}/{{.Name}}.out
+StandardError=file:{{.LogDirectory}}/{{.Name}}.err
+{{- end}}
+{{if gt .LimitNOFILE -1 }}LimitNOFILE={{.LimitNOFILE}}{{end}}
+{{if .Restart}}Restart={{.Restart}}{{end}}
+{{if .SuccessExitStatus}}SuccessExitStatus={{.SuccessExitStatus}}{{end}}
+RestartSec=120
+EnvironmentFile=-/etc/sysconfig/{{.Name}}
+
+{{range $k, $v := .EnvVars -}}
+Environment={{$k}}={{$v}}
+{{end -}}
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// Apply 返回合并后的 service.Config，不修改 svcConfig；
+// 未指定 SystemdScript 和 ReloadSignal 时使用支持平滑重启的 unit，systemctl reload 发送 SIGHUP
+func (d *DaemonConfig) Apply(svcConfig *service.Config) *service.Config {
+	config := &service.Config{}
+	if svcConfig != nil {
+		*config = *svcConfig
+	}
+	config.Option = maps.Clone(config.Option)
+	if config.Option == nil {
+		config.Option = service.KeyValue{}
+	}
+	if _, ok := config.Option["SystemdScript"]; !ok {
+		config.Option["SystemdScript"] = systemdScript
+	}
+	if _, ok := config.Option["ReloadSignal"]; !ok {
+		config.Option["ReloadSignal"] = "HUP"
+	}
+	if len(d.Name) > 0 {
+		config.Name = d.Name
+	}
+	if len(d.DisplayName) > 0 {
+		config.DisplayName = d.DisplayName
+	}
+	if len(d.Description) > 0 {
+		config.Description = d.Description
+	}
+	if len(d.Arguments) > 0 {
+		config.Arguments = d.Arguments
+	}
+	if len(d.WorkingDirectory) > 0 {
+		config.WorkingDirectory = d.WorkingDirectory
+	}
+	if len(d.UserName) > 0 {
+		config.UserName = d.UserName
+	}
+	if len(d.Env) > 0 {
+		envVars := make(map[string]string, len(config.EnvVars)+len(d.Env))
+		for key, value := range config.EnvVars {
+			envVars[key] = value
+		}
+		for _, env := range d.Env {
+			key, value, _ := strings.Cut(env, "=")
+			envVars[key] = value
+		}
+		config.EnvVars = envVars
+	}
+	return config
+}
+
+// daemonLogger 将服务管理器相关的日志写入 log，非交互运行时同时写入系统日志
+type daemonLogger struct {
+	system service.Logger
+}
+
+func newDaemonLogger(svc service.Service) *daemonLogger {
+	logger := &daemonLogger{}
+	if !service.Interactive() {
+		system, err := svc.SystemLogger(nil)
+		if err != nil {
+			log.Errors("Failed to open the system logger", err)
+		} else {
+			logger.system = system
+		}
+	}
+	return logger
+}
+
+func (l *daemonLogger) Error(v ...any) error {
+	log.Error(fmt.Sprint(v...))
+	if l.system != nil {
+		return l.system.Error(v...)
+	}
+	return nil
+}
+
+func (l *daemonLogger) Warning(v ...any) error {
+	log.Warn(fmt.Sprint(v...))
+	if l.system != nil {
+		return l.system.Warning(v...)
+	}
+	return nil
+}
+
+func (l *daemonLogger) Info(v ...any) error {
+	log.Info(fmt.Sprint(v...))
+	if l.system != nil {
+		return l.system.Info(v...)
+	}
+	return nil
+}
+
+func (l *daemonLogger) Errorf(format string, a ...any) error {
+	return l.Error(fmt.Sprintf(format, a...))
+}
+
+func (l *daemonLogger) Warningf(format string, a ...any) error {
+	return l.Warning(fmt.Sprintf(format, a...))
+}
+
+func (l *daemonLogger) Infof(format string, a ...any) error {
+	return l.Info(fmt.Sprintf(format, a...))
+}
+
+// RunDaemon 按 args 中第一个不以 - 开头的参数执行 install、uninstall、start、stop、restart、status 或 run，
+// 没有该参数时为 run，由服务管理器启动时也是 run，返回退出码
+func RunDaemon(appService AppService, svcConfig *service.Config, args ...string) int {
+	action, ok := daemonAction(args)
+	if !ok {
+		fmt.Fprintln(os.Stderr, daemonUsage)
+		return ExitUsage
+	}
 	app := &AppDaemon{
 		appService: appService,
+		exited:     make(chan struct{}),
 	}
+	svcConfig = withRunWait(svcConfig, app.wait)
 	svc, err := service.New(app, svcConfig)
 	if err != nil {
 		log.Errors("Failed to create the Daemon service", err)
-		os.Exit(1)
+		return ExitFailure
 	}
-	// 处理停止指令
-	if *stopFlag {
-		if err := svc.Stop(); err != nil {
-			log.Errors("Failed to stop the Daemon service", err)
-			os.Exit(1)
+	logger := newDaemonLogger(svc)
+	app.logger = logger
+	switch {
+	case action == "run":
+		// Windows：服务控制管理器；Linux：systemd；macOS：launchd
+		err := svc.Run()
+		if err != nil {
+			logger.Errorf("Failed to run the Daemon service: %+v", err)
+			return ExitFailure
+		}
+		// Run 成功返回时已调用过 Start，等待 appService.Start 返回后再读取 startErr
+		<-app.exited
+		if app.startErr != nil {
+			return ExitFailure
 		}
-		fmt.Println("The Daemon service has been stopped")
-		os.Exit(0)
+		return ExitOK
+	case action == "status":
+		status, err := svc.Status()
+		switch {
+		case errors.Is(err, service.ErrNotInstalled):
+			fmt.Println("not installed")
+			return ExitNotInstalled
+		case err != nil:
+			logger.Errorf("Failed to get the Daemon service status: %+v", err)
+			return ExitNotInstalled
+		case status == service.StatusRunning:
+			fmt.Println("running")
+			return ExitOK
+		case status == service.StatusStopped:
+			fmt.Println("stopped")
+			return ExitStopped
+		default:
+			fmt.Println("unknown")
+			return ExitNotInstalled
+		}
+	case slices.Contains(service.ControlAction[:], action):
+		err := service.Control(svc, action)
+		if err != nil {
+			logger.Errorf("Failed to %s the Daemon service: %+v", action, err)
+			return ExitFailure
+		}
+		fmt.Printf("The Daemon service %s: %s\n", svcConfig.Name, action)
+		return ExitOK
+	default:
+		fmt.Fprintln(os.Stderr, daemonUsage)
+		return ExitUsage
 	}
+}
 
-	// 启动服务（Windows：注册为服务；Linux：systemd；macOS：launchd）
-	if err := svc.Run(); err != nil {
-		log.Errors("Failed to run the Daemon service", err)
-		os.Exit(1)
+// daemonAction 返回 args 中第一个不以 - 开头的参数，-stop 为 stop 的旧写法；
+// 以 - 开头的参数只接受 --key=value 形式的配置覆盖，其他参数返回 false
+func daemonAction(args []string) (string, bool) {
+	action := ""
+	for _, arg := range args {
+		switch {
+		case arg == "-stop" || arg == "--stop":
+			if len(action) > 0 && action != "stop" {
+				return "", false
+			}
+			action = "stop"
+		case strings.HasPrefix(arg, "--") && strings.Contains(arg, "="):
+		case strings.HasPrefix(arg, "-"):
+			return "", false
+		case len(action) == 0:
+			action = arg
+		}
 	}
+	if len(action) == 0 {
+		return "run", true
+	}
+	return action, true
+}
 
+// withRunWait 未指定 RunWait 时使用 wait，不修改 svcConfig
+func withRunWait(svcConfig *service.Config, wait func()) *service.Config {
+	if svcConfig == nil {
+		return nil
+	}
+	if _, ok := svcConfig.Option["RunWait"]; ok {
+		return svcConfig
+	}
+	config := *svcConfig
+	config.Option = make(service.KeyValue, len(svcConfig.Option)+1)
+	maps.Copy(config.Option, svcConfig.Option)
+	config.Option["RunWait"] = wait
+	return &config
 }
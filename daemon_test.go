@@ -0,0 +1,58 @@
+package wf
+
+import (
+	"strings"
+	"testing"
+
+	config2 "github.com/chuccp/go-web-frame/config"
+	"github.com/kardianos/service"
+)
+
+func TestDaemonConfig(t *testing.T) {
+	config := config2.NewConfig()
+	config.Put("web.daemon.userName", "www")
+	config.Put("web.daemon.env", []string{"GOMAXPROCS=2", "TZ=Asia/Shanghai"})
+	var daemonConfig DaemonConfig
+	err := config.Unmarshal(daemonConfig.Key(), &daemonConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svcConfig := daemonConfig.Apply(&service.Config{Name: "app", UserName: "root", EnvVars: map[string]string{"TZ": "UTC"}})
+	if svcConfig.Name != "app" || svcConfig.UserName != "www" || svcConfig.EnvVars["TZ"] != "Asia/Shanghai" || svcConfig.EnvVars["GOMAXPROCS"] != "2" {
+		t.Fatalf("unexpected service config %+v", svcConfig)
+	}
+	if script, _ := svcConfig.Option["SystemdScript"].(string); !strings.Contains(script, "NotifyAccess=main") || svcConfig.Option["ReloadSignal"] != "HUP" {
+		t.Fatalf("the systemd unit should support graceful restart: %v", svcConfig.Option)
+	}
+
+	config.Put("web.daemon.env", []string{"TZ"})
+	if err := config.Unmarshal(daemonConfig.Key(), &DaemonConfig{}); err == nil {
+		t.Fatal("env without value should be rejected")
+	}
+
+	if code := New(config2.NewConfig()).Daemon(&service.Config{Name: "app"}, "unknown"); code != ExitUsage {
+		t.Fatalf("expected exit code %d, got %d", ExitUsage, code)
+	}
+}
+
+func TestDaemonAction(t *testing.T) {
+	for _, c := range []struct {
+		args   []string
+		action string
+		ok     bool
+	}{
+		{nil, "run", true},
+		{[]string{"--web.server.port=8080"}, "run", true},
+		{[]string{"status", "--web.server.port=8080"}, "status", true},
+		{[]string{"-stop"}, "stop", true},
+		{[]string{"--stop"}, "stop", true},
+		{[]string{"-start"}, "", false},
+		{[]string{"--verbose"}, "", false},
+		{[]string{"start", "-stop"}, "", false},
+	} {
+		action, ok := daemonAction(c.args)
+		if action != c.action || ok != c.ok {
+			t.Fatalf("%v: expected %q %v, got %q %v", c.args, c.action, c.ok, action, ok)
+		}
+	}
+}
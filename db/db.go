@@ -1,6 +1,8 @@
 package db
 
 import (
+	"context"
+
 	"emperror.dev/errors"
 	"github.com/chuccp/go-web-frame/config"
 	"github.com/chuccp/go-web-frame/util"
@@ -33,7 +35,7 @@ func (t *Table) Session(g *gorm.Session) *Session {
 }
 
 func (t *Table) AutoMigrate(v ...any) error {
-	return t.db.AutoMigrate(v)
+	return t.db.AutoMigrate(v...)
 }
 
 func (t *Table) Delete(value any, conds ...any) error {
@@ -107,6 +109,19 @@ func (d *DB) Transaction(fc func(tx *DB) error) error {
 	})
 }
 
+// WithContext 返回使用 ctx 的 DB，SQL 日志包含 ctx 中的请求 ID，请求取消时查询随之取消
+func (d *DB) WithContext(ctx context.Context) *DB {
+	return &DB{db: d.db.WithContext(ctx)}
+}
+
+func (d *DB) Ping(ctx context.Context) error {
+	sqlDB, err := d.db.DB()
+	if err != nil {
+		return errors.WithStackIf(err)
+	}
+	return errors.WithStackIf(sqlDB.PingContext(ctx))
+}
+
 func (d *DB) Migrator() gorm.Migrator {
 	return d.db.Migrator()
 }
@@ -138,34 +153,48 @@ type IConfig interface {
 	Connection() (*DB, error)
 }
 type Config struct {
-	Type string
+	Type string `validate:"required,oneof=mysql sqlite"`
 }
 
 const ConfigKey = "web.db"
 
-func CreateDB(c config.IConfig) (*DB, error) {
-	var config2 Config
-	err := c.Unmarshal(ConfigKey, &config2)
-	if err != nil {
-		return nil, err
-	}
-	if util.IsNotBlank(config2.Type) {
-		if util.EqualsAnyIgnoreCase(config2.Type, MYSQL) {
-			var mysqlConfig MysqlConfig
-			err := c.Unmarshal(ConfigKey, &mysqlConfig)
-			if err != nil {
-				return nil, err
-			}
-			return mysqlConfig.Connection()
+// 数据库的配置项不在配置文件中时也可以通过环境变量设置
+func init() {
+	config.Register(ConfigKey, &Config{})
+	config.Register(ConfigKey, &MysqlConfig{})
+	config.Register(ConfigKey, &SQLiteConfig{})
+}
+
+// LoadConfig 按 web.db.type 解析对应数据库的配置，未配置 type 时返回 NoConfigDBError
+func LoadConfig(c config.IConfig) (IConfig, error) {
+	dbType := c.GetString(ConfigKey + ".type")
+	if util.EqualsAnyIgnoreCase(dbType, MYSQL) {
+		var mysqlConfig MysqlConfig
+		err := c.Unmarshal(ConfigKey, &mysqlConfig)
+		if err != nil {
+			return nil, err
 		}
-		if util.EqualsAnyIgnoreCase(config2.Type, SQLITE) {
-			var sqliteConfig SQLiteConfig
-			err := c.Unmarshal(ConfigKey, &sqliteConfig)
-			if err != nil {
-				return nil, err
-			}
-			return sqliteConfig.Connection()
+		return &mysqlConfig, nil
+	}
+	if util.EqualsAnyIgnoreCase(dbType, SQLITE) {
+		var sqliteConfig SQLiteConfig
+		err := c.Unmarshal(ConfigKey, &sqliteConfig)
+		if err != nil {
+			return nil, err
 		}
+		return &sqliteConfig, nil
+	}
+	if util.IsNotBlank(dbType) {
+		var config2 Config
+		return nil, c.Unmarshal(ConfigKey, &config2)
 	}
 	return nil, errors.WithStackIf(NoConfigDBError)
 }
+
+func CreateDB(c config.IConfig) (*DB, error) {
+	dbConfig, err := LoadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return dbConfig.Connection()
+}
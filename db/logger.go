@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"emperror.dev/errors"
+	log2 "github.com/chuccp/go-web-frame/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SlowThreshold 超过该时间的 SQL 以 warn 级别输出
+var SlowThreshold = 200 * time.Millisecond
+
+// gormLogger 通过 log.Ctx 输出 SQL，使用 WithContext 查询时日志包含请求 ID
+type gormLogger struct {
+	level logger.LogLevel
+}
+
+func newLogger() logger.Interface {
+	return &gormLogger{level: logger.Info}
+}
+
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &gormLogger{level: level}
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Info {
+		log2.Ctx(ctx).Info(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Warn {
+		log2.Ctx(ctx).Warn(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Error {
+		log2.Ctx(ctx).Error(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	sql, rows := fc()
+	fields := []zap.Field{zap.String("sql", sql), zap.Int64("rows", rows), zap.Duration("elapsed", elapsed)}
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		log2.Ctx(ctx).Error("sql", append(fields, zap.Error(err))...)
+	case elapsed > SlowThreshold && l.level >= logger.Warn:
+		log2.Ctx(ctx).Warn("slow sql", fields...)
+	case l.level >= logger.Info:
+		log2.Ctx(ctx).Info("sql", fields...)
+	}
+}
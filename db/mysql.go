@@ -9,7 +9,6 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 type MysqlConfig struct {
@@ -19,8 +18,8 @@ type MysqlConfig struct {
 	Username string
 	User     string
 	Password string
-	Host     string
-	Port     int
+	Host     string `validate:"required"`
+	Port     int    `validate:"min=1,max=65535"`
 }
 
 func (mysqlConfig *MysqlConfig) Connection() (db *DB, err error) {
@@ -38,7 +37,7 @@ func (mysqlConfig *MysqlConfig) Connection() (db *DB, err error) {
 	}
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local", mysqlConfig.Username, mysqlConfig.Password, mysqlConfig.Host, mysqlConfig.Port, mysqlConfig.Database, mysqlConfig.Charset)
 	log2.Debug("mysql", zap.String("dsn", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local", mysqlConfig.Username, "******", mysqlConfig.Host, mysqlConfig.Port, mysqlConfig.Database, mysqlConfig.Charset)))
-	db_, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Info)})
+	db_, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: newLogger()})
 	if err != nil {
 		return nil, errors.WithStackIf(err)
 	}
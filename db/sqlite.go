@@ -6,16 +6,15 @@ import (
 	"github.com/chuccp/go-web-frame/sqlite"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 type SQLiteConfig struct {
-	FilePath string
+	FilePath string `validate:"required"`
 }
 
 func (sqliteConfig *SQLiteConfig) Connection() (db *DB, err error) {
 	log2.Debug("sqlite", zap.String("dsn", sqliteConfig.FilePath))
-	sb, err := gorm.Open(sqlite.Open(sqliteConfig.FilePath), &gorm.Config{Logger: logger.Default.LogMode(logger.Info)})
+	sb, err := gorm.Open(sqlite.Open(sqliteConfig.FilePath), &gorm.Config{Logger: newLogger()})
 	if err != nil {
 		return nil, errors.WithStackIf(err)
 	}
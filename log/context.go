@@ -0,0 +1,107 @@
+package log
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type fieldsKey struct{}
+
+type requestIDKey struct{}
+
+// RequestIDField 日志中请求 ID 的字段名
+const RequestIDField = "requestId"
+
+// WithFields 返回带有日志字段的 ctx，Ctx(ctx) 输出的日志都包含这些字段
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	parent, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	merged := make([]zap.Field, 0, len(parent)+len(fields))
+	merged = append(merged, parent...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// WithRequestID 返回带有请求 ID 的 ctx，请求、定时任务执行时使用
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return WithFields(ctx, zap.String(RequestIDField, requestID))
+}
+
+// RequestID 返回 ctx 中的请求 ID，没有时为空
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Logger 带有固定字段的日志，通过 Ctx 获取
+type Logger struct {
+	fields []zap.Field
+}
+
+// Ctx 返回输出 ctx 中日志字段（如请求 ID）的 Logger
+func Ctx(ctx context.Context) *Logger {
+	if ctx == nil {
+		return &Logger{}
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	return &Logger{fields: fields}
+}
+
+// With 返回追加了字段的 Logger
+func (l *Logger) With(fields ...zap.Field) *Logger {
+	merged := make([]zap.Field, 0, len(l.fields)+len(fields))
+	merged = append(merged, l.fields...)
+	merged = append(merged, fields...)
+	return &Logger{fields: merged}
+}
+
+func (l *Logger) with(fields []zap.Field) []zap.Field {
+	if len(l.fields) == 0 {
+		return fields
+	}
+	merged := make([]zap.Field, 0, len(l.fields)+len(fields))
+	merged = append(merged, l.fields...)
+	return append(merged, fields...)
+}
+
+// Log 按 lvl 输出日志
+func (l *Logger) Log(lvl zapcore.Level, msg string, fields ...zap.Field) {
+	lock.RLock()
+	defer lock.RUnlock()
+	defaultLogger.log(lvl, msg, l.with(fields)...)
+}
+
+func (l *Logger) Info(msg string, fields ...zap.Field) {
+	lock.RLock()
+	defer lock.RUnlock()
+	defaultLogger.info(msg, l.with(fields)...)
+}
+func (l *Logger) Error(msg string, fields ...zap.Field) {
+	lock.RLock()
+	defer lock.RUnlock()
+	defaultLogger.error(msg, l.with(fields)...)
+}
+func (l *Logger) Errors(msg string, errs ...error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	fields := make([]zap.Field, len(errs))
+	for i, e := range errs {
+		fields[i] = zap.Error(e)
+	}
+	defaultLogger.error(msg, l.with(fields)...)
+}
+func (l *Logger) Debug(msg string, fields ...zap.Field) {
+	lock.RLock()
+	defer lock.RUnlock()
+	defaultLogger.debug(msg, l.with(fields)...)
+}
+func (l *Logger) Warn(msg string, fields ...zap.Field) {
+	lock.RLock()
+	defer lock.RUnlock()
+	defaultLogger.warn(msg, l.with(fields)...)
+}
@@ -21,6 +21,13 @@ type Config struct {
 func (c *Config) Key() string {
 	return "web.log"
 }
+
+// Validate 检查日志级别
+func (c *Config) Validate() error {
+	_, err := ParseLevel(c.Level)
+	return err
+}
+
 func defaultConfig() *Config {
 	return &Config{
 		Level: "info",
@@ -38,6 +45,9 @@ func IsBackgroundMode() bool {
 }
 
 var TimestampFormat = "2006-01-02 15:04:05"
+
+// level 全局日志级别，修改后立即对所有输出生效
+var level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
 var defaultLogger = getDefaultLogger()
 
 func getEncoder() zapcore.Encoder {
@@ -54,12 +64,25 @@ func getFileLogWriter(path string) zapcore.Core {
 		Compress:   true, // disabled by default
 	}
 	encoder := getEncoder()
-	core := zapcore.NewCore(encoder, zapcore.AddSync(logger), zapcore.InfoLevel)
+	core := zapcore.NewCore(encoder, zapcore.AddSync(logger), zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= zapcore.InfoLevel && level.Enabled(l)
+	}))
 	return core
 }
+
+// stdoutSyncer 终端和管道不支持 fsync，Sync 时忽略 stdout 返回的错误
+type stdoutSyncer struct {
+	*os.File
+}
+
+func (s stdoutSyncer) Sync() error {
+	_ = s.File.Sync()
+	return nil
+}
+
 func getStdoutLogWriter() zapcore.Core {
 	encoder := getEncoder()
-	core := zapcore.NewCore(encoder, os.Stdout, zapcore.DebugLevel)
+	core := zapcore.NewCore(encoder, stdoutSyncer{File: os.Stdout}, level)
 	return core
 }
 
@@ -87,6 +110,10 @@ func (l *logger) panic(msg string, fields ...zap.Field) {
 	l.zap.Panic(msg, fields...)
 }
 
+func (l *logger) log(lvl zapcore.Level, msg string, fields ...zap.Field) {
+	l.zap.Log(lvl, msg, fields...)
+}
+
 func (l *logger) sync() error {
 	return l.zap.Sync()
 }
@@ -172,13 +199,36 @@ func getDefaultLogger() *logger {
 		zap: l,
 	}
 }
+
+// ParseLevel 解析日志级别，为空时为 info
+func ParseLevel(text string) (zapcore.Level, error) {
+	if len(text) == 0 {
+		return zapcore.InfoLevel, nil
+	}
+	return zapcore.ParseLevel(text)
+}
+
+// SetLevel 修改日志级别，无需重新初始化日志
+func SetLevel(text string) error {
+	l, err := ParseLevel(text)
+	if err != nil {
+		return err
+	}
+	if l != level.Level() {
+		level.SetLevel(l)
+		Info("log level changed", zap.String("level", l.String()))
+	}
+	return nil
+}
+
 func InitLogger(logConfig *Config) {
 	mode := logConfig.Write
-	level, err := zapcore.ParseLevel(logConfig.Level)
+	l, err := ParseLevel(logConfig.Level)
 	if err != nil {
-		level = zapcore.InfoLevel
-		Error("log level", zap.Error(err), zap.String("level", level.String()))
+		l = zapcore.InfoLevel
+		Error("log level", zap.Error(err), zap.String("level", l.String()))
 	}
+	level.SetLevel(l)
 	Info("Running Mode", zap.String("level", logConfig.Level), zap.Bool("run in the background", mode))
 	if !mode {
 		if len(logConfig.Path) > 0 {
@@ -187,7 +237,7 @@ func InitLogger(logConfig *Config) {
 				logConfig.Path = abs
 				Info(" log save path", zap.String("logPath", logConfig.Path))
 				cores := zapcore.NewTee(getFileLogWriter(logConfig.Path), getStdoutLogWriter())
-				l := zap.New(cores, zap.AddCaller(), zap.AddCallerSkip(2), zap.IncreaseLevel(level))
+				l := zap.New(cores, zap.AddCaller(), zap.AddCallerSkip(2))
 				lock.Lock()
 				defer lock.Unlock()
 				defaultLogger = &logger{
@@ -203,6 +253,6 @@ func InitLogger(logConfig *Config) {
 	lock.Lock()
 	defer lock.Unlock()
 	defaultLogger = &logger{
-		zap: zap.New(getStdoutLogWriter(), zap.AddCaller(), zap.AddCallerSkip(2), zap.IncreaseLevel(level)),
+		zap: zap.New(getStdoutLogWriter(), zap.AddCaller(), zap.AddCallerSkip(2)),
 	}
 }
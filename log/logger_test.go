@@ -1,10 +1,14 @@
 package log
 
 import (
+	"context"
 	"log"
 	"testing"
 
 	"emperror.dev/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestName(t *testing.T) {
@@ -28,3 +32,34 @@ func TestName2(t *testing.T) {
 		log.Printf("%+v\n", err)
 	}
 }
+
+func TestCtx(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	lock.Lock()
+	previous := defaultLogger
+	defaultLogger = &logger{zap: zap.New(core)}
+	lock.Unlock()
+	defer func() {
+		lock.Lock()
+		defaultLogger = previous
+		lock.Unlock()
+	}()
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	if RequestID(ctx) != "req-1" {
+		t.Fatal("request id not stored")
+	}
+	Ctx(ctx).With(zap.String("job", "sync")).Info("done", zap.Int("count", 2))
+	Ctx(context.Background()).Warn("plain")
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+	fields := entries[0].ContextMap()
+	if fields[RequestIDField] != "req-1" || fields["job"] != "sync" || fields["count"] != int64(2) {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	if _, ok := entries[1].ContextMap()[RequestIDField]; ok {
+		t.Fatal("request id should not leak into other contexts")
+	}
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"time"
 
 	"github.com/chuccp/go-web-frame/db"
@@ -23,12 +24,20 @@ func NewEntryModel[T IEntry](db *db.DB, tableName string) *EntryModel[T] {
 	return &EntryModel[T]{NewModel[T](db, tableName)}
 }
 
+// WithContext 返回使用 ctx 查询的 EntryModel，SQL 日志包含 ctx 中的请求 ID
+func (a *EntryModel[T]) WithContext(ctx context.Context) *EntryModel[T] {
+	return &EntryModel[T]{a.model.WithContext(ctx)}
+}
+
 func (a *EntryModel[T]) IsExist() bool {
 	return a.model.IsExist()
 }
 func (a *EntryModel[T]) CreateTable() error {
 	return a.model.CreateTable()
 }
+func (a *EntryModel[T]) Migrate() error {
+	return a.model.Migrate()
+}
 func (a *EntryModel[T]) DeleteTable() error {
 	return a.model.DeleteTable()
 }
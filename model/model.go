@@ -1,6 +1,8 @@
 package model
 
 import (
+	"context"
+
 	"emperror.dev/errors"
 	"github.com/chuccp/go-web-frame/db"
 	"github.com/chuccp/go-web-frame/util"
@@ -23,6 +25,15 @@ func (a *Model[T]) CreateTable() error {
 	t := util.NewPtr(a.entry)
 	return errors.WithStackIf(a.db.Table(a.tableName).AutoMigrate(t))
 }
+
+// Migrate 表不存在时调用 CreateTable 创建，存在时补充新增的字段和索引
+func (a *Model[T]) Migrate() error {
+	if !a.IsExist() {
+		return a.CreateTable()
+	}
+	t := util.NewPtr(a.entry)
+	return errors.WithStackIf(a.db.Table(a.tableName).AutoMigrate(t))
+}
 func (a *Model[T]) DeleteTable() error {
 	t := util.NewPtr(a.entry)
 	err := a.db.Table(a.tableName).Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(t)
@@ -53,6 +64,11 @@ func (a *Model[T]) Delete() *Delete[T] {
 	return &Delete[T]{tx: tx, model: a.entry, wheres: NewDeleteWheres[T](tx, a.entry)}
 }
 
+// WithContext 返回使用 ctx 查询的 Model，SQL 日志包含 ctx 中的请求 ID
+func (a *Model[T]) WithContext(ctx context.Context) *Model[T] {
+	return &Model[T]{db: a.db.WithContext(ctx), tableName: a.tableName, entry: a.entry}
+}
+
 func NewModel[T any](db *db.DB, tableName string) *Model[T] {
 	var entryPtr T
 	return &Model[T]{db: db, tableName: tableName, entry: util.NewPtr(entryPtr)}
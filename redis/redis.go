@@ -1,6 +1,9 @@
 package redis
 
 import (
+	"context"
+
+	"emperror.dev/errors"
 	config2 "github.com/chuccp/go-web-frame/config"
 	"github.com/redis/go-redis/v9"
 )
@@ -11,7 +14,7 @@ type Component struct {
 	client *redis.Client
 }
 
-func (l *Component) Init(config *config2.Config) error {
+func (l *Component) Init(config config2.IConfig) error {
 	var options = redis.Options{}
 	err := config.Unmarshal("web.redis", &options)
 	if err != nil {
@@ -20,9 +23,29 @@ func (l *Component) Init(config *config2.Config) error {
 	l.client = redis.NewClient(&options)
 	return nil
 }
+
+// ValidateConfig 启动前校验 web.redis 配置
+func (l *Component) ValidateConfig(config config2.IConfig) error {
+	var options = redis.Options{}
+	return config.Unmarshal("web.redis", &options)
+}
 func (l *Component) GetClient() *redis.Client {
 	return l.client
 }
 func (l *Component) Name() string {
 	return Name
 }
+
+func (l *Component) HealthCheck(ctx context.Context) error {
+	if l.client == nil {
+		return errors.New("redis client is not initialized")
+	}
+	return errors.WithStackIf(l.client.Ping(ctx).Err())
+}
+
+func (l *Component) Destroy() error {
+	if l.client == nil {
+		return nil
+	}
+	return errors.WithStackIf(l.client.Close())
+}
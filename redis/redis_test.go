@@ -13,5 +13,6 @@ func TestName(t *testing.T) {
 		Password: "", // no password set
 		DB:       0,  // use default DB
 	})
+	defer rdb.Close()
 
 }
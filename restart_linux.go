@@ -0,0 +1,140 @@
+package wf
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/log"
+	"github.com/chuccp/go-web-frame/web"
+	"go.uber.org/zap"
+)
+
+// restartReadyEnv 新进程就绪后写入该文件描述符通知旧进程
+const restartReadyEnv = "WEB_RESTART_READY_FD"
+
+// restartSignals 收到后平滑重启：新进程继承监听端口，就绪后旧进程处理完进行中的请求再退出
+var restartSignals = []os.Signal{syscall.SIGHUP, syscall.SIGUSR2}
+
+// restart 以相同的参数启动新进程并传递所有监听端口，等待新进程就绪，新进程启动失败时旧进程继续服务
+func (w *WebFrame) restart(ctx context.Context) error {
+	addrs, files, err := w.server.ListenerFiles()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}()
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return errors.WithStackIf(err)
+	}
+	defer readyReader.Close()
+	executable, err := os.Executable()
+	if err != nil {
+		_ = readyWriter.Close()
+		return errors.WithStackIf(err)
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = append(files, readyWriter)
+	cmd.Env = append(restartEnviron(),
+		web.ListenFdsEnv+"="+strings.Join(addrs, ","),
+		restartReadyEnv+"="+strconv.Itoa(3+len(files)),
+	)
+	err = cmd.Start()
+	_ = readyWriter.Close()
+	if err != nil {
+		return errors.WrapIf(err, "start the new process")
+	}
+	log.Info("Started the new process", zap.Int("pid", cmd.Process.Pid), zap.Strings("address", addrs))
+	// 新进程继续使用 unix socket 文件，旧进程关闭时不能删除，新进程未就绪时恢复
+	w.server.SetUnlinkOnClose(false)
+
+	ready := make(chan error, 1)
+	go func() {
+		_, err := readyReader.Read(make([]byte, 1))
+		if errors.Is(err, io.EOF) {
+			err = errors.New("the new process exited before ready")
+		}
+		ready <- err
+	}()
+	timeout := time.NewTimer(w.lifecycleConfig.GetRestartTimeout())
+	defer timeout.Stop()
+	select {
+	case err = <-ready:
+	case <-timeout.C:
+		err = errors.New("timed out waiting for the new process")
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		go cmd.Wait()
+		w.server.SetUnlinkOnClose(true)
+		return errors.WithStackIf(err)
+	}
+	_ = cmd.Process.Release()
+	notifySystemd("MAINPID=" + strconv.Itoa(cmd.Process.Pid))
+	return nil
+}
+
+// restartEnviron 去掉上一次重启留下的环境变量
+func restartEnviron() []string {
+	environ := make([]string, 0)
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, web.ListenFdsEnv+"=") || strings.HasPrefix(env, restartReadyEnv+"=") {
+			continue
+		}
+		environ = append(environ, env)
+	}
+	return environ
+}
+
+// notifyRestarted 由平滑重启启动的新进程在就绪后通知旧进程
+func notifyRestarted() {
+	value := os.Getenv(restartReadyEnv)
+	if len(value) == 0 {
+		return
+	}
+	_ = os.Unsetenv(restartReadyEnv)
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		log.Error("Invalid restart ready fd", zap.String("fd", value))
+		return
+	}
+	file := os.NewFile(uintptr(fd), "restart-ready")
+	defer file.Close()
+	_, err = file.Write([]byte{1})
+	if err != nil {
+		log.Error("Failed to notify the old process", zap.Error(err))
+	}
+}
+
+// notifySystemd 由 systemd 启动时将主进程更新为新进程，unit 需要配置 NotifyAccess=main，
+// Daemon 安装的 unit 已配置
+func notifySystemd(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if len(socket) == 0 {
+		return
+	}
+	conn, err := net.Dial("unixgram", socket)
+	if err != nil {
+		log.Error("Failed to notify systemd", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	if err != nil {
+		log.Error("Failed to notify systemd", zap.Error(err))
+	}
+}
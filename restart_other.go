@@ -0,0 +1,20 @@
+//go:build !linux
+
+package wf
+
+import (
+	"context"
+	"os"
+
+	"emperror.dev/errors"
+)
+
+// restartSignals 平滑重启只支持 Linux
+var restartSignals []os.Signal
+
+func (w *WebFrame) restart(ctx context.Context) error {
+	return errors.New("graceful restart is only supported on linux")
+}
+
+func notifyRestarted() {
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
 	"encoding/base64"
 
 	"emperror.dev/errors"
@@ -74,6 +75,10 @@ func DecryptByCBC(cipherText string, key string, iv string) (string, error) {
 		return "", errors.New("The ciphertext length must be an integer multiple of 16 bytes")
 	}
 
+	if len(ciphertext) == 0 {
+		return "", errors.New("The ciphertext is empty")
+	}
+
 	// 创建CBC模式的解密流
 	mode := cipher.NewCBCDecrypter(block, []byte(iv))
 
@@ -90,3 +95,53 @@ func DecryptByCBC(cipherText string, key string, iv string) (string, error) {
 	// 转换为字符串返回
 	return string(plaintext), nil
 }
+
+// EncryptByGCM AES-256-GCM 加密实现，随机生成 nonce 放在密文前面，密文被篡改时解密失败
+func EncryptByGCM(text string, key string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.WithStackIf(err)
+	}
+	ciphertext := gcm.Seal(nonce, nonce, []byte(text), nil)
+	return base64.URLEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptByGCM AES-256-GCM 解密实现，同时校验密文是否被篡改
+func DecryptByGCM(cipherText string, key string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := base64.URLEncoding.DecodeString(cipherText)
+	if err != nil {
+		return "", errors.WithStackIf(err)
+	}
+	if len(ciphertext) < gcm.NonceSize()+gcm.Overhead() {
+		return "", errors.New("The ciphertext is too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.WrapIf(err, "The ciphertext is invalid or the key is wrong")
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key string) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("The key length of AES-256 must be 32 bytes")
+	}
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+	return gcm, nil
+}
@@ -16,3 +16,21 @@ func TestEncryptByCBC(t *testing.T) {
 	}
 
 }
+
+func TestEncryptByGCM(t *testing.T) {
+	key := "12345678901234567890123456789012"
+	text := "hello world"
+	cipherText, err := EncryptByGCM(text, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plainText, err := DecryptByGCM(cipherText, key)
+	if err != nil || plainText != text {
+		t.Fatalf("DecryptByGCM failed, expected %s, got %s, %v", text, plainText, err)
+	}
+	tampered := []byte(cipherText)
+	tampered[len(tampered)/2] ^= 1
+	if _, err := DecryptByGCM(string(tampered), key); err == nil {
+		t.Fatal("tampered ciphertext should not be decrypted")
+	}
+}
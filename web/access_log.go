@@ -0,0 +1,132 @@
+package web
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/log"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AccessLogConfig 访问日志，通过 log 输出，与其他日志使用相同的格式和文件
+type AccessLogConfig struct {
+	Enabled       bool
+	Sample        float64  `validate:"min=0,max=1"` // 2xx、3xx 请求的记录比例，4xx、5xx 和慢请求总是记录
+	Exclude       []string // 不记录的路由，如 /health，以 * 结尾时匹配前缀
+	SlowThreshold int      `validate:"min=0"` // 慢请求阈值 单位毫秒，超过时以 warn 级别记录，为 0 时不区分
+	Level2xx      string   // 默认 info
+	Level3xx      string   // 默认 info
+	Level4xx      string   // 默认 warn
+	Level5xx      string   // 默认 error
+}
+
+func DefaultAccessLogConfig() *AccessLogConfig {
+	return &AccessLogConfig{
+		Enabled:       true,
+		Sample:        1,
+		SlowThreshold: 1000,
+		Level2xx:      "info",
+		Level3xx:      "info",
+		Level4xx:      "warn",
+		Level5xx:      "error",
+	}
+}
+
+func (a *AccessLogConfig) Validate() error {
+	for _, level := range []string{a.Level2xx, a.Level3xx, a.Level4xx, a.Level5xx} {
+		if _, err := log.ParseLevel(level); err != nil {
+			return errors.WrapIff(err, "access log level %q", level)
+		}
+	}
+	return nil
+}
+
+// level 按状态码类别返回日志级别，未配置时为 info
+func (a *AccessLogConfig) level(status int) zapcore.Level {
+	text := a.Level2xx
+	switch {
+	case status >= 500:
+		text = a.Level5xx
+	case status >= 400:
+		text = a.Level4xx
+	case status >= 300:
+		text = a.Level3xx
+	}
+	level, err := log.ParseLevel(text)
+	if err != nil {
+		return zapcore.InfoLevel
+	}
+	return level
+}
+
+func (a *AccessLogConfig) slow(latency time.Duration) bool {
+	return a.SlowThreshold > 0 && latency >= time.Duration(a.SlowThreshold)*time.Millisecond
+}
+
+// userID 返回 Authentication.User 返回的用户的 ID，用户实现 GetId() uint 或 fmt.Stringer 时使用其返回值
+func userID(context *gin.Context) string {
+	user, ok := context.Get(UserKey)
+	if !ok || user == nil {
+		return ""
+	}
+	switch u := user.(type) {
+	case interface{ GetId() uint }:
+		return fmt.Sprint(u.GetId())
+	case fmt.Stringer:
+		return u.String()
+	case string:
+		return u
+	case int, int64, uint, uint64:
+		return fmt.Sprint(u)
+	}
+	return ""
+}
+
+// handle 在请求处理完成后记录访问日志
+func (a *AccessLogConfig) handle(context *gin.Context) {
+	if !a.Enabled {
+		return
+	}
+	start := time.Now()
+	path := context.Request.URL.Path
+	context.Next()
+	fullPath := context.FullPath()
+	if matchPath(a.Exclude, fullPath, path) {
+		return
+	}
+	latency := time.Since(start)
+	status := context.Writer.Status()
+	slow := a.slow(latency)
+	if status < 400 && !slow && a.Sample < 1 && rand.Float64() >= a.Sample {
+		return
+	}
+	level := a.level(status)
+	if slow && level < zapcore.WarnLevel {
+		level = zapcore.WarnLevel
+	}
+	if len(fullPath) == 0 {
+		fullPath = path
+	}
+	fields := []zap.Field{
+		zap.String("method", context.Request.Method),
+		zap.String("path", fullPath),
+		zap.Int("status", status),
+		zap.Duration("latency", latency),
+		zap.Int("bytes", max(context.Writer.Size(), 0)),
+		zap.String("clientIp", context.ClientIP()),
+	}
+	if id := userID(context); len(id) > 0 {
+		fields = append(fields, zap.String("userId", id))
+	}
+	if slow {
+		fields = append(fields, zap.Bool("slow", true))
+	}
+	if errs := context.Errors.ByType(gin.ErrorTypeAny); len(errs) > 0 {
+		fields = append(fields, zap.String("errors", errs.String()))
+	}
+	log.Ctx(context.Request.Context()).Log(level, "access", fields...)
+}
@@ -0,0 +1,52 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zapcore"
+)
+
+type testUser struct {
+	id uint
+}
+
+func (u *testUser) GetId() uint {
+	return u.id
+}
+
+func TestAccessLog(t *testing.T) {
+	config := DefaultAccessLogConfig()
+	for status, expected := range map[int]zapcore.Level{200: zapcore.InfoLevel, 302: zapcore.InfoLevel, 404: zapcore.WarnLevel, 503: zapcore.ErrorLevel} {
+		if level := config.level(status); level != expected {
+			t.Fatalf("%d: expected %s, got %s", status, expected, level)
+		}
+	}
+	if !config.slow(2*time.Second) || config.slow(10*time.Millisecond) {
+		t.Fatal("unexpected slow request threshold")
+	}
+	if err := (&AccessLogConfig{Level2xx: "verbose"}).Validate(); err == nil {
+		t.Fatal("invalid level should be rejected")
+	}
+
+	serverConfig := DefaultServerConfig()
+	serverConfig.AccessLog.Exclude = []string{"/health*"}
+	httpServer := NewHttpServer(serverConfig, NewCertManager())
+	var user string
+	httpServer.GET("/user/:id", func(context *gin.Context) {
+		context.Set(UserKey, &testUser{id: 7})
+		context.String(http.StatusOK, "ok")
+		user = userID(context)
+	})
+	w := httptest.NewRecorder()
+	httpServer.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/1", nil))
+	if w.Code != http.StatusOK || user != "7" {
+		t.Fatalf("unexpected response %d, user %q", w.Code, user)
+	}
+	if !matchPath(serverConfig.AccessLog.Exclude, "", "/healthz") {
+		t.Fatal("excluded path should match")
+	}
+}
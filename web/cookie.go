@@ -1,6 +1,8 @@
 package web
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -43,6 +45,11 @@ func (c *Cookie) Forever(key string, value string) {
 func (c *Cookie) ForeverDomain(domain string, key string, value string) {
 	c.c.SetCookie(key, value, 0, "/", domain, false, true)
 }
+
+// SetCookie 写入自定义属性的 cookie，如非 HttpOnly、SameSite
+func (c *Cookie) SetCookie(cookie *http.Cookie) {
+	http.SetCookie(c.c.Writer, cookie)
+}
 func NewCookie(c *gin.Context) *Cookie {
 	return &Cookie{c: c}
 }
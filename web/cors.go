@@ -0,0 +1,125 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	defaultCorsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead, http.MethodOptions}
+	defaultCorsHeaders = []string{"Origin", "Content-Length", "Content-Type"}
+)
+
+// CorsConfig 跨域配置，未配置时不返回任何 CORS 响应头
+type CorsConfig struct {
+	AllowAll         bool     // 允许任意来源携带凭据访问，只用于开发环境
+	AllowOrigins     []string // 如 https://example.com、https://*.example.com，* 表示任意来源
+	AllowMethods     []string // 默认 GET、POST、PUT、PATCH、DELETE、HEAD、OPTIONS
+	AllowHeaders     []string // 默认 Origin、Content-Length、Content-Type
+	ExposeHeaders    []string
+	MaxAge           int `validate:"min=0"` // 预检请求缓存时间 单位秒
+	AllowCredentials bool
+}
+
+func (c *CorsConfig) Validate() error {
+	for _, origin := range c.AllowOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 || (len(u.Path) > 0 && u.Path != "/") {
+			return errors.Errorf("allowOrigins: %q must be scheme://host[:port]", origin)
+		}
+		if host := strings.TrimPrefix(u.Host, "*."); strings.Contains(host, "*") {
+			return errors.Errorf("allowOrigins: %q only supports a leading *. wildcard", origin)
+		}
+	}
+	return nil
+}
+
+// allowOrigin 来源是否允许，支持 https://*.example.com 匹配任意级子域名
+func (c *CorsConfig) allowOrigin(origin string) bool {
+	if c.AllowAll {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, allowed := range c.AllowOrigins {
+		allowed = strings.ToLower(strings.TrimSuffix(allowed, "/"))
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(allowed, "://*."); ok {
+			scheme, host, found := strings.Cut(origin, "://")
+			if found && scheme == prefix && strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *CorsConfig) anyOrigin() bool {
+	return !c.AllowAll && !c.AllowCredentials && slices.Contains(c.AllowOrigins, "*")
+}
+
+func (c *CorsConfig) allowCredentials() bool {
+	return c.AllowAll || c.AllowCredentials
+}
+
+func orDefault(values []string, defaultValues []string) string {
+	if len(values) == 0 {
+		values = defaultValues
+	}
+	return strings.Join(values, ", ")
+}
+
+// handle 写入 CORS 响应头，预检请求直接返回 204
+func (c *CorsConfig) handle(context *gin.Context) {
+	origin := context.GetHeader("Origin")
+	header := context.Writer.Header()
+	header.Add("Vary", "Origin")
+	if len(origin) == 0 || !c.allowOrigin(origin) {
+		return
+	}
+	if c.anyOrigin() {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.allowCredentials() {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	preflight := context.Request.Method == http.MethodOptions && len(context.GetHeader("Access-Control-Request-Method")) > 0
+	if !preflight {
+		if len(c.ExposeHeaders) > 0 {
+			header.Set("Access-Control-Expose-Headers", strings.Join(c.ExposeHeaders, ", "))
+		}
+		return
+	}
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+	header.Set("Access-Control-Allow-Methods", orDefault(c.AllowMethods, defaultCorsMethods))
+	header.Set("Access-Control-Allow-Headers", orDefault(c.AllowHeaders, defaultCorsHeaders))
+	if c.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(c.MaxAge))
+	}
+	context.AbortWithStatus(http.StatusNoContent)
+}
+
+// corsHandle 按路径前缀选择跨域配置，在 engine 上执行以便处理未注册 OPTIONS 路由的预检请求
+func (httpServer *HttpServer) corsHandle(context *gin.Context) {
+	if config, ok := httpServer.cors.match(context.Request.URL.Path); ok && config != nil {
+		config.handle(context)
+	}
+}
+
+// Cors 为路径前缀设置跨域配置，prefix 为 / 时作用于整个端口
+func (httpServer *HttpServer) Cors(prefix string, config *CorsConfig) {
+	httpServer.cors.add(prefix, config)
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCors(t *testing.T) {
+	serverConfig := DefaultServerConfig()
+	serverConfig.Cors = &CorsConfig{
+		AllowOrigins:     []string{"https://example.com", "https://*.example.org"},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost},
+		ExposeHeaders:    []string{"X-Total"},
+		MaxAge:           600,
+		AllowCredentials: true,
+	}
+	if err := serverConfig.Cors.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := NewHttpServer(serverConfig, NewCertManager())
+	httpServer.Cors("/open", &CorsConfig{AllowAll: true})
+	ok := func(context *gin.Context) {
+		context.String(http.StatusOK, "ok")
+	}
+	httpServer.POST("/api", ok)
+	httpServer.GET("/open/data", ok)
+
+	request := func(method, path, origin string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		w := httptest.NewRecorder()
+		httpServer.ServeHTTP(w, r)
+		return w
+	}
+
+	for _, origin := range []string{"https://example.com", "https://a.example.org", "https://a.b.example.org"} {
+		w := request(http.MethodPost, "/api", origin)
+		if w.Header().Get("Access-Control-Allow-Origin") != origin || w.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+			t.Fatalf("%s: unexpected headers %v", origin, w.Header())
+		}
+	}
+	for _, origin := range []string{"https://evil.com", "http://example.com", "https://example.org", "https://aexample.org"} {
+		w := request(http.MethodPost, "/api", origin)
+		if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Fatalf("%s: should get no CORS headers, got %v", origin, w.Header())
+		}
+	}
+
+	w := request(http.MethodOptions, "/api", "https://example.com")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") != "GET, POST" ||
+		w.Header().Get("Access-Control-Max-Age") != "600" || w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("unexpected preflight response %d %v", w.Code, w.Header())
+	}
+
+	w = request(http.MethodGet, "/open/data", "https://evil.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://evil.com" {
+		t.Fatalf("allowAll should allow any origin, got %v", w.Header())
+	}
+
+	if err := (&CorsConfig{AllowOrigins: []string{"https://a.*.com"}}).Validate(); err == nil {
+		t.Fatal("invalid wildcard should be rejected")
+	}
+}
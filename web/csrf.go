@@ -0,0 +1,107 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"emperror.dev/errors"
+)
+
+// CsrfTokenKey gin.Context 中保存当前请求 CSRF token 的键
+const CsrfTokenKey = "web.csrfToken"
+
+const (
+	DefaultCsrfCookieName = "csrf_token"
+	DefaultCsrfHeaderName = "X-CSRF-Token"
+	DefaultCsrfFormField  = "csrf_token"
+)
+
+var ErrCsrfToken = errors.Sentinel("invalid csrf token")
+
+// CsrfConfig 双重提交 token 的 CSRF 防护，token 保存在 cookie 中，
+// 非安全方法的请求必须在请求头或表单字段中提交相同的 token
+type CsrfConfig struct {
+	CookieName string   // 默认 csrf_token
+	HeaderName string   // 默认 X-CSRF-Token，响应中同样通过该请求头返回 token
+	FormField  string   // 默认 csrf_token
+	MaxAge     int      `validate:"min=0"` // cookie 有效期 单位秒，为 0 时为会话 cookie
+	Exempt     []string // 不校验的路由，如 /webhook/:id，以 * 结尾时匹配前缀
+}
+
+func (c *CsrfConfig) GetCookieName() string {
+	if len(c.CookieName) == 0 {
+		return DefaultCsrfCookieName
+	}
+	return c.CookieName
+}
+
+func (c *CsrfConfig) GetHeaderName() string {
+	if len(c.HeaderName) == 0 {
+		return DefaultCsrfHeaderName
+	}
+	return c.HeaderName
+}
+
+func (c *CsrfConfig) GetFormField() string {
+	if len(c.FormField) == 0 {
+		return DefaultCsrfFormField
+	}
+	return c.FormField
+}
+
+func (c *CsrfConfig) exempt(request *Request) bool {
+	return matchPath(c.Exempt, request.FullPath(), request.GinContext().Request.URL.Path)
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func newCsrfToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// token 返回 cookie 中的 token，没有时生成新的 token 并写入 cookie，
+// cookie 不设置 HttpOnly 以便前端脚本读取后放入请求头
+func (c *CsrfConfig) token(request *Request) string {
+	token := request.Cookie().Get(c.GetCookieName())
+	if len(token) > 0 {
+		return token
+	}
+	token = newCsrfToken()
+	request.Cookie().SetCookie(&http.Cookie{
+		Name:     c.GetCookieName(),
+		Value:    token,
+		Path:     "/",
+		MaxAge:   c.MaxAge,
+		Secure:   request.GinContext().Request.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return token
+}
+
+// Handle 在 gin.Context 和响应头中提供 token，非安全方法的请求 token 不一致时返回 403
+func (c *CsrfConfig) Handle(request *Request) (any, error) {
+	token := c.token(request)
+	request.GinContext().Set(CsrfTokenKey, token)
+	request.GinContext().Header(c.GetHeaderName(), token)
+	if safeMethod(request.GinContext().Request.Method) || c.exempt(request) {
+		return nil, nil
+	}
+	submitted := request.GinContext().GetHeader(c.GetHeaderName())
+	if len(submitted) == 0 {
+		submitted = request.GinContext().PostForm(c.GetFormField())
+	}
+	if len(submitted) == 0 || subtle.ConstantTimeCompare([]byte(submitted), []byte(request.Cookie().Get(c.GetCookieName()))) != 1 {
+		return Forbidden(nil, ErrCsrfToken), nil
+	}
+	return nil, nil
+}
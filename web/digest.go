@@ -19,6 +19,9 @@ type Authentication interface {
 	NewUser() any
 }
 
+// UserKey gin.Context 中保存本次请求 Authentication 返回的用户的键，访问日志从中读取用户 ID
+const UserKey = "web.user"
+
 type DigestAuth struct {
 	authentication Authentication
 }
@@ -29,18 +32,24 @@ func (digestAuth *DigestAuth) Authentication() Authentication {
 
 func (digestAuth *DigestAuth) SignIn(user any, request *Request) (any, error) {
 	if digestAuth.authentication != nil {
+		request.GinContext().Set(UserKey, user)
 		return digestAuth.authentication.SignIn(user, request)
 	}
 	return nil, errors.New("secretProvider is nil")
 }
 func (digestAuth *DigestAuth) User(request *Request) (any, error) {
 	if digestAuth.authentication != nil {
-		return digestAuth.authentication.User(request)
+		user, err := digestAuth.authentication.User(request)
+		if err == nil && user != nil {
+			request.GinContext().Set(UserKey, user)
+		}
+		return user, err
 	}
 	return nil, errors.New("secretProvider is nil")
 }
 
 func (digestAuth *DigestAuth) SignOut(r *Request) (any, error) {
+	r.GinContext().Set(UserKey, nil)
 	return digestAuth.authentication.SignOut(r)
 
 }
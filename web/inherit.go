@@ -0,0 +1,109 @@
+package web
+
+import (
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/log"
+	"go.uber.org/zap"
+)
+
+// ListenFdsEnv 平滑重启时由旧进程设置，按顺序列出继承的监听地址，对应的文件描述符从 3 开始
+const ListenFdsEnv = "WEB_LISTEN_FDS"
+
+const listenFdsStart = 3
+
+var inherited = struct {
+	once      sync.Once
+	lock      sync.Mutex
+	listeners map[string]net.Listener
+}{listeners: make(map[string]net.Listener)}
+
+// loadInheritedListeners 读取后清除环境变量，避免再次重启时被子进程误用
+func loadInheritedListeners() {
+	value := os.Getenv(ListenFdsEnv)
+	if len(value) == 0 {
+		return
+	}
+	_ = os.Unsetenv(ListenFdsEnv)
+	for i, addr := range strings.Split(value, ",") {
+		file := os.NewFile(uintptr(listenFdsStart+i), addr)
+		if file == nil {
+			continue
+		}
+		listener, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			log.Error("Failed to inherit the listener", zap.String("address", addr), zap.Error(err))
+			continue
+		}
+		inherited.listeners[addr] = listener
+	}
+}
+
+// inheritedListener 返回从旧进程继承的监听，每个地址只能取一次
+func inheritedListener(addr string) net.Listener {
+	inherited.once.Do(loadInheritedListeners)
+	inherited.lock.Lock()
+	defer inherited.lock.Unlock()
+	listener, ok := inherited.listeners[addr]
+	if ok {
+		delete(inherited.listeners, addr)
+	}
+	return listener
+}
+
+// listen 优先使用按 key 继承的监听，否则绑定新的地址
+func listen(network string, address string, key string) (net.Listener, error) {
+	if listener := inheritedListener(key); listener != nil {
+		log.Info("Inherited the listener", zap.String("address", key))
+		return listener, nil
+	}
+	if network == "unix" {
+		removeStaleSocket(address)
+	}
+	listener, err := net.Listen(network, address)
+	return listener, errors.WithStackIf(err)
+}
+
+// removeStaleSocket 上次退出时未删除的 socket 文件无法再次绑定，没有进程监听时删除
+func removeStaleSocket(path string) {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return
+	}
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		_ = conn.Close()
+		return
+	}
+	_ = os.Remove(path)
+}
+
+type filer interface {
+	File() (*os.File, error)
+}
+
+// SetUnlinkOnClose 监听 unix socket 时设置关闭后是否删除 socket 文件，
+// 新进程启动成功后继续使用该文件，旧进程关闭时不能删除
+func (httpServer *HttpServer) SetUnlinkOnClose(unlink bool) {
+	if unixListener, ok := httpServer.listener.(*net.UnixListener); ok {
+		unixListener.SetUnlinkOnClose(unlink)
+	}
+}
+
+// ListenerFile 返回监听的文件描述符副本，用于传递给新进程
+func (httpServer *HttpServer) ListenerFile() (*os.File, error) {
+	if httpServer.listener == nil {
+		return nil, errors.Errorf("port %d is not listening", httpServer.Port())
+	}
+	f, ok := httpServer.listener.(filer)
+	if !ok {
+		return nil, errors.Errorf("listener of port %d can not be inherited", httpServer.Port())
+	}
+	file, err := f.File()
+	return file, errors.WithStackIf(err)
+}
@@ -90,6 +90,17 @@ func Unauthorized(data any, msg ...error) *Message {
 		Data: data,
 	}
 }
+func Forbidden(data any, msg ...error) *Message {
+	m := "forbidden"
+	if len(msg) > 0 {
+		m = msg[0].Error()
+	}
+	return &Message{
+		Code: http.StatusForbidden,
+		Msg:  m,
+		Data: data,
+	}
+}
 func Redirect(url string) *Message {
 	return &Message{
 		Code: http.StatusMovedPermanently,
@@ -97,3 +108,10 @@ func Redirect(url string) *Message {
 		Data: url,
 	}
 }
+func ServiceUnavailable(data any) *Message {
+	return &Message{
+		Code: http.StatusServiceUnavailable,
+		Msg:  "service unavailable",
+		Data: data,
+	}
+}
@@ -0,0 +1,52 @@
+package web
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+type pathPolicy[T any] struct {
+	prefix string
+	value  T
+}
+
+// pathPolicies 按路径前缀保存配置，匹配时前缀最长的优先
+type pathPolicies[T any] struct {
+	lock     sync.RWMutex
+	policies []*pathPolicy[T]
+}
+
+func (p *pathPolicies[T]) add(prefix string, value T) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.policies = slices.DeleteFunc(p.policies, func(policy *pathPolicy[T]) bool { return policy.prefix == prefix })
+	p.policies = append(p.policies, &pathPolicy[T]{prefix: prefix, value: value})
+	slices.SortStableFunc(p.policies, func(a, b *pathPolicy[T]) int { return len(b.prefix) - len(a.prefix) })
+}
+
+func (p *pathPolicies[T]) match(path string) (T, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	for _, policy := range p.policies {
+		if policy.prefix == "/" || path == policy.prefix || strings.HasPrefix(path, strings.TrimSuffix(policy.prefix, "/")+"/") {
+			return policy.value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// matchPath 按路由模板或请求路径匹配，以 * 结尾时匹配前缀
+func matchPath(patterns []string, fullPath string, path string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(fullPath, prefix) || strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if pattern == fullPath || pattern == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"syscall"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/log"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PanicHook 处理函数 panic 后调用，err 包含 panic 时的调用栈，可用于上报错误
+type PanicHook func(request *Request, err error)
+
+// OnPanic 添加 panic 后调用的 hook，需要在开始服务前添加
+func (httpServer *HttpServer) OnPanic(hook ...PanicHook) {
+	httpServer.panicHooks = append(httpServer.panicHooks, hook...)
+}
+
+// panicError 将 panic 的值转换为错误，在 recover 所在的 defer 中调用，调用栈从 panic 的位置开始
+func panicError(p any) error {
+	err, ok := p.(error)
+	if !ok {
+		err = errors.NewPlain(fmt.Sprint(p))
+	}
+	return errors.WithStackDepth(errors.WithMessage(err, "panic"), 3)
+}
+
+// brokenPipe 客户端已断开连接，无法再写入响应
+func brokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// recoveryHandle 捕获 panic，记录带调用栈的日志并调用 hook，未写入响应时返回 500 的 Message
+func (httpServer *HttpServer) recoveryHandle(context *gin.Context) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		if p == http.ErrAbortHandler {
+			panic(p)
+		}
+		err := panicError(p)
+		logger := log.Ctx(context.Request.Context()).With(zap.String("method", context.Request.Method), zap.String("path", context.Request.URL.Path))
+		if brokenPipe(err) {
+			logger.Warn("The connection was closed by the client", zap.String("error", err.Error()))
+			_ = context.Error(err)
+			context.Abort()
+			return
+		}
+		logger.Error("panic recovered", zap.Error(err))
+		request := NewRequest(context, nil)
+		for _, hook := range httpServer.panicHooks {
+			httpServer.runPanicHook(hook, request, err)
+		}
+		if context.Writer.Written() {
+			context.Abort()
+			return
+		}
+		context.AbortWithStatusJSON(http.StatusInternalServerError, ErrorMessage(http.StatusText(http.StatusInternalServerError)))
+	}()
+	context.Next()
+}
+
+// runPanicHook hook 自身 panic 时只记录日志
+func (httpServer *HttpServer) runPanicHook(hook PanicHook, request *Request, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Ctx(request.Context()).Error("panic hook", zap.Error(panicError(p)))
+		}
+	}()
+	hook(request, err)
+}
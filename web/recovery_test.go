@@ -0,0 +1,46 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecovery(t *testing.T) {
+	httpServer := NewHttpServer(DefaultServerConfig(), NewCertManager())
+	var hookErrs []error
+	httpServer.OnPanic(func(request *Request, err error) {
+		hookErrs = append(hookErrs, err)
+	}, func(request *Request, err error) {
+		panic("broken hook")
+	})
+	httpServer.GET("/handler", ToGinHandlerFunc(nil, func(req *Request) (any, error) {
+		var m map[string]int
+		m["boom"]++
+		return nil, nil
+	})...)
+	httpServer.GET("/raw", ToGinHandlerRawFunc(nil, func(req *Request, resp Response) error {
+		panic("raw handler failed")
+	})...)
+
+	for _, path := range []string{"/handler", "/raw"} {
+		w := httptest.NewRecorder()
+		httpServer.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var message Message
+		if err := json.Unmarshal(w.Body.Bytes(), &message); err != nil {
+			t.Fatalf("%s: %v %q", path, err, w.Body.String())
+		}
+		if w.Code != http.StatusInternalServerError || message.Code != http.StatusInternalServerError || message.Msg != "Internal Server Error" {
+			t.Fatalf("%s: unexpected response %d %+v", path, w.Code, message)
+		}
+	}
+	if len(hookErrs) != 2 {
+		t.Fatalf("expected 2 hook calls, got %d", len(hookErrs))
+	}
+	if stack := fmt.Sprintf("%+v", hookErrs[1]); !strings.Contains(stack, "raw handler failed") || !strings.Contains(stack, "recovery_test.go") {
+		t.Fatalf("error should contain the panic stack: %s", stack)
+	}
+}
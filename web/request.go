@@ -1,11 +1,14 @@
 package web
 
 import (
+	"context"
+	"crypto/x509"
 	"net/http"
 	"reflect"
 	"strings"
 
 	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/log"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cast"
@@ -31,14 +34,26 @@ type Request struct {
 	cookie     *Cookie
 	jsonBody   *JsonObject
 	digestAuth *DigestAuth
+	next       func()
 }
 
 // Next should be used only inside middleware.
 // It executes the pending handlers in the chain inside the calling handler.
 // See example in GitHub.
 func (r *Request) Next() {
+	if r.next != nil {
+		r.next()
+		return
+	}
 	r.c.Next()
 }
+
+// WithNext 返回 Next 执行 next 的请求副本，用于在一个处理函数内组合多个中间件
+func (r *Request) WithNext(next func()) *Request {
+	request := *r
+	request.next = next
+	return &request
+}
 func (r *Request) FullPath() string {
 	return r.c.FullPath()
 }
@@ -73,6 +88,40 @@ func User[T any](r *Request) (T, error) {
 
 }
 
+// ClientCertificate 双向认证时返回已校验的客户端证书，未校验或非 https 请求时返回 nil
+func (r *Request) ClientCertificate() *x509.Certificate {
+	state := r.c.Request.TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
+
+// CSPNonce 当前请求的 CSP nonce，ContentSecurityPolicy 中没有 {nonce} 时为空
+func (r *Request) CSPNonce() string {
+	return r.c.GetString(CSPNonceKey)
+}
+
+// RequestID 当前请求的 X-Request-ID
+func (r *Request) RequestID() string {
+	return r.c.GetString(RequestIDKey)
+}
+
+// Context 当前请求的 context.Context，包含请求 ID，查询数据库和调用下游服务时传入
+func (r *Request) Context() context.Context {
+	return r.c.Request.Context()
+}
+
+// Logger 输出的日志包含当前请求的请求 ID
+func (r *Request) Logger() *log.Logger {
+	return log.Ctx(r.Context())
+}
+
+// CsrfToken 当前请求的 CSRF token，路由组未启用 CSRF 防护时为空
+func (r *Request) CsrfToken() string {
+	return r.c.GetString(CsrfTokenKey)
+}
+
 func (r *Request) RemoteAddr() string {
 	return r.c.Request.RemoteAddr
 }
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/chuccp/go-web-frame/log"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader 请求 ID 的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey gin.Context 中保存请求 ID 的键
+const RequestIDKey = "web.requestId"
+
+const maxRequestIDLength = 128
+
+// validRequestID 只接受长度有限的可见 ASCII 字符，避免日志注入
+func validRequestID(requestID string) bool {
+	if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDHandle 沿用请求中合法的 X-Request-ID，否则生成新的 ID，写入响应头和 request.Context()
+func requestIDHandle(context *gin.Context) {
+	requestID := context.GetHeader(RequestIDHeader)
+	if !validRequestID(requestID) {
+		requestID = uuid.NewString()
+	}
+	context.Set(RequestIDKey, requestID)
+	context.Header(RequestIDHeader, requestID)
+	context.Request = context.Request.WithContext(log.WithRequestID(context.Request.Context(), requestID))
+}
+
+type requestIDTransport struct {
+	base http.RoundTripper
+}
+
+func (t *requestIDTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	requestID := log.RequestID(request.Context())
+	if len(requestID) == 0 || len(request.Header.Get(RequestIDHeader)) > 0 {
+		return t.base.RoundTrip(request)
+	}
+	request = request.Clone(request.Context())
+	request.Header.Set(RequestIDHeader, requestID)
+	return t.base.RoundTrip(request)
+}
+
+// NewRequestIDTransport 将请求 ctx 中的请求 ID 通过 X-Request-ID 转发给下游服务，base 为 nil 时使用 http.DefaultTransport：
+//
+//	client := &http.Client{Transport: web.NewRequestIDTransport(nil)}
+//	request, _ := http.NewRequestWithContext(req.Context(), http.MethodGet, url, nil)
+func NewRequestIDTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &requestIDTransport{base: base}
+}
+
+// HTTPClient 转发请求 ID 的 http.Client
+var HTTPClient = &http.Client{Transport: NewRequestIDTransport(nil)}
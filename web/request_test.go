@@ -1,7 +1,56 @@
 package web
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
 
 func TestPage(t *testing.T) {
 
 }
+
+func TestRequestID(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get(RequestIDHeader)))
+	}))
+	defer downstream.Close()
+
+	httpServer := NewHttpServer(DefaultServerConfig(), NewCertManager())
+	httpServer.GET("/", func(context *gin.Context) {
+		req := NewRequest(context, nil)
+		request, _ := http.NewRequestWithContext(req.Context(), http.MethodGet, downstream.URL, nil)
+		response, err := HTTPClient.Do(request)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer response.Body.Close()
+		forwarded := make([]byte, 256)
+		n, _ := response.Body.Read(forwarded)
+		context.String(http.StatusOK, req.RequestID()+" "+string(forwarded[:n]))
+	})
+	get := func(requestID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(requestID) > 0 {
+			r.Header.Set(RequestIDHeader, requestID)
+		}
+		w := httptest.NewRecorder()
+		httpServer.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("abc-123")
+	if w.Header().Get(RequestIDHeader) != "abc-123" || w.Body.String() != "abc-123 abc-123" {
+		t.Fatalf("request id not accepted or forwarded: %q %q", w.Header().Get(RequestIDHeader), w.Body.String())
+	}
+	for _, requestID := range []string{"", "bad id\n"} {
+		w = get(requestID)
+		generated := w.Header().Get(RequestIDHeader)
+		if len(generated) == 0 || generated == requestID || w.Body.String() != generated+" "+generated {
+			t.Fatalf("%q: unexpected request id %q %q", requestID, generated, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	serverConfig := DefaultServerConfig()
+	serverConfig.Security.ContentSecurityPolicy = "default-src 'self'; script-src 'self' 'nonce-{nonce}'"
+	httpServer := NewHttpServer(serverConfig, NewCertManager())
+	httpServer.Security("/api", &SecurityConfig{Enabled: true, ContentSecurityPolicy: "default-src 'none'", FrameOptions: FrameOptionsDeny})
+	httpServer.GET("/page", func(context *gin.Context) {
+		context.String(http.StatusOK, NewRequest(context, nil).CSPNonce())
+	})
+	httpServer.GET("/api/data", func(context *gin.Context) {
+		context.String(http.StatusOK, "ok")
+	})
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		httpServer.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/page")
+	nonce := w.Body.String()
+	csp := w.Header().Get("Content-Security-Policy")
+	if len(nonce) == 0 || csp != "default-src 'self'; script-src 'self' 'nonce-"+nonce+"'; frame-ancestors 'self'" {
+		t.Fatalf("unexpected CSP %q with nonce %q", csp, nonce)
+	}
+	if get("/page").Body.String() == nonce {
+		t.Fatal("nonce should change per request")
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" || w.Header().Get("X-Frame-Options") != "SAMEORIGIN" {
+		t.Fatalf("unexpected headers %v", w.Header())
+	}
+	if w.Header().Get("Strict-Transport-Security") != "" {
+		t.Fatal("HSTS should only be sent when SSL is enabled")
+	}
+	if w := get("/missing"); w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatal("security headers should also apply to unmatched paths")
+	}
+
+	w = get("/api/data")
+	if w.Header().Get("Content-Security-Policy") != "default-src 'none'; frame-ancestors 'none'" || w.Header().Get("X-Content-Type-Options") != "" {
+		t.Fatalf("rest group override not applied: %v", w.Header())
+	}
+
+	serverConfig = DefaultServerConfig()
+	serverConfig.SSL.Enabled = true
+	serverConfig.Security.HSTSIncludeSubdomains = true
+	httpServer = NewHttpServer(serverConfig, NewCertManager())
+	if hsts := get("/").Header().Get("Strict-Transport-Security"); !strings.HasPrefix(hsts, "max-age=31536000") || !strings.HasSuffix(hsts, "includeSubDomains") {
+		t.Fatalf("unexpected HSTS %q", hsts)
+	}
+}
@@ -0,0 +1,180 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/log"
+	"github.com/chuccp/go-web-frame/util"
+	"go.uber.org/zap"
+)
+
+const (
+	SSLModeACME       = "acme"
+	SSLModeSelfSigned = "self-signed"
+)
+
+// CertsDir ACME 证书缓存以及自签名证书的目录
+var CertsDir = "certs"
+
+const (
+	localCAName      = "local-ca"
+	localCAValidity  = 10 * 365 * 24 * time.Hour
+	selfSignedExpiry = 365 * 24 * time.Hour
+	// selfSignedRenew 剩余有效期不足时重新签发
+	selfSignedRenew = 30 * 24 * time.Hour
+)
+
+// LocalCAFile 本地 CA 证书，加入系统或浏览器的信任列表后自签名证书不再提示不安全
+func LocalCAFile() string {
+	return filepath.Join(CertsDir, localCAName+".pem")
+}
+
+// ensureSelfSigned 返回 localhost、127.0.0.1、::1 以及 hosts 的证书，不存在、即将过期或域名变化时由本地 CA 重新签发
+func ensureSelfSigned(hosts []string) (*CertConfig, error) {
+	err := util.CreateDirIfNoExists(CertsDir)
+	if err != nil {
+		return nil, err
+	}
+	ca, caKey, err := loadOrCreateLocalCA()
+	if err != nil {
+		return nil, err
+	}
+	names := selfSignedNames(hosts)
+	sum := sha256.Sum256([]byte(strings.Join(names, ",")))
+	name := "self-signed-" + hex.EncodeToString(sum[:4])
+	pair := &CertConfig{
+		CertFile: filepath.Join(CertsDir, name+".pem"),
+		KeyFile:  filepath.Join(CertsDir, name+".key"),
+	}
+	if validSelfSigned(pair, ca, names) {
+		return pair, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: names[0], Organization: []string{"go-web-frame development"}},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(selfSignedExpiry),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, n := range names {
+		if ip := net.ParseIP(n); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, n)
+		}
+	}
+	_, err = createCertificate(template, ca, caKey, key, pair.CertFile, pair.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Issued the self-signed certificate", zap.Strings("hosts", names), zap.String("ca", LocalCAFile()))
+	return pair, nil
+}
+
+func selfSignedNames(hosts []string) []string {
+	names := []string{"localhost", "127.0.0.1", "::1"}
+	for _, host := range hosts {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(strings.TrimSpace(host))
+		if len(host) > 0 && !slices.Contains(names, host) {
+			names = append(names, host)
+		}
+	}
+	return names
+}
+
+// validSelfSigned 证书存在、由当前 CA 签发、包含所有域名且不会很快过期
+func validSelfSigned(pair *CertConfig, ca *x509.Certificate, names []string) bool {
+	tlsCert, err := tls.LoadX509KeyPair(pair.CertFile, pair.KeyFile)
+	if err != nil {
+		return false
+	}
+	cert := tlsCert.Leaf
+	if cert == nil || time.Until(cert.NotAfter) < selfSignedRenew || cert.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+	for _, n := range names {
+		if cert.VerifyHostname(n) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func loadOrCreateLocalCA() (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certFile, keyFile := LocalCAFile(), filepath.Join(CertsDir, localCAName+".key")
+	tlsCert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err == nil && tlsCert.Leaf != nil && time.Until(tlsCert.Leaf.NotAfter) > selfSignedExpiry {
+		if key, ok := tlsCert.PrivateKey.(*ecdsa.PrivateKey); ok {
+			return tlsCert.Leaf, key, nil
+		}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, errors.WithStackIf(err)
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "go-web-frame local CA", Organization: []string{"go-web-frame development"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(localCAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	ca, err := createCertificate(template, template, key, key, certFile, keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Info("Created the local CA", zap.String("ca", certFile))
+	return ca, key, nil
+}
+
+// createCertificate 由 parent 签发证书并写入文件，私钥文件只有当前用户可读
+func createCertificate(template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, key *ecdsa.PrivateKey, certFile string, keyFile string) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+	template.SerialNumber = serial
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	if err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	if err != nil {
+		return nil, errors.WithStackIf(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, errors.WithStackIf(err)
+}
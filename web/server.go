@@ -1,8 +1,11 @@
 package web
 
 import (
+	"context"
 	"crypto/tls"
+	"net"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -12,12 +15,12 @@ import (
 	"emperror.dev/errors"
 	"github.com/chuccp/go-web-frame/log"
 	"github.com/chuccp/go-web-frame/util"
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/sourcegraph/conc/panics"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/acme/autocert"
 	"golang.org/x/net/http2"
+	"golang.org/x/net/netutil"
 )
 
 const MaxHeaderBytes = 8192
@@ -26,23 +29,147 @@ const MaxReadHeaderTimeout = time.Second * 30
 
 const MaxReadTimeout = time.Minute * 10
 
+// DefaultShutdownTimeout 优雅关闭时等待进行中请求完成的默认时间，单位秒
+const DefaultShutdownTimeout = 30
+
 type SSLConfig struct {
-	Enabled bool
-	Hosts   []string
+	Enabled      bool
+	Mode         string        `validate:"oneof=acme self-signed"` // self-signed 时由 certs 目录下的本地 CA 为 localhost 和 Hosts 签发证书，用于本地开发
+	Hosts        []string      // 通过 ACME 自动申请证书的域名
+	CertFile     string        // 证书文件，与 KeyFile 成对使用
+	KeyFile      string        // 私钥文件
+	Certs        []*CertConfig // 多个证书，按 SNI 选择
+	ClientCAFile string        // 校验客户端证书的 CA，设置后启用双向认证
+	ClientAuth   string        `validate:"oneof=none request require verify-if-given require-and-verify"` // 设置 ClientCAFile 时默认 require-and-verify
+}
+
+func (s *SSLConfig) SelfSigned() bool {
+	return s.Mode == SSLModeSelfSigned
+}
+
+// CertPairs 返回 CertFile/KeyFile 和 Certs 中配置的所有证书
+func (s *SSLConfig) CertPairs() []*CertConfig {
+	pairs := make([]*CertConfig, 0, len(s.Certs)+1)
+	if len(s.CertFile) > 0 || len(s.KeyFile) > 0 {
+		pairs = append(pairs, &CertConfig{CertFile: s.CertFile, KeyFile: s.KeyFile})
+	}
+	return append(pairs, s.Certs...)
+}
+
+func (s *SSLConfig) GetClientAuth() tls.ClientAuthType {
+	if len(s.ClientAuth) == 0 {
+		if len(s.ClientCAFile) > 0 {
+			return tls.RequireAndVerifyClientCert
+		}
+		return tls.NoClientCert
+	}
+	return clientAuthTypes[s.ClientAuth]
 }
+
+func (s *SSLConfig) Validate() error {
+	for _, pair := range s.CertPairs() {
+		if len(pair.CertFile) == 0 || len(pair.KeyFile) == 0 {
+			return errors.New("certFile and keyFile must be set together")
+		}
+	}
+	clientAuth := s.GetClientAuth()
+	if len(s.ClientCAFile) == 0 && (clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert) {
+		return errors.Errorf("clientAuth %s requires clientCAFile", s.ClientAuth)
+	}
+	return nil
+}
+
 type ServerConfig struct {
-	Port      int
-	Locations []string
-	Page404   string
-	SSL       *SSLConfig
+	Port            int    `validate:"required,min=1,max=65535"`
+	Host            string // 绑定的地址，为空时监听所有地址，管理端口可设置为 127.0.0.1
+	Listen          string // 完整的监听地址，优先于 Host 和 Port，如 127.0.0.1:8080、unix:/run/app.sock，此时 Port 只用于区分服务
+	SocketMode      string // unix socket 文件权限，八进制，如 0660
+	Locations       []string
+	Page404         string
+	Debug           bool // 旧的配置文件中使用，已不再生效，保留以免未知配置项校验失败
+	SSL             *SSLConfig
+	Cors            *CorsConfig      // 跨域配置，为空时不返回 CORS 响应头
+	Security        *SecurityConfig  // 安全响应头，为空时不发送
+	AccessLog       *AccessLogConfig // 访问日志，为空时不记录
+	ShutdownTimeout int              `validate:"min=0"` // 优雅关闭超时时间 单位秒
+	ReadTimeout     int              `validate:"min=0"` // 读取整个请求的超时时间 单位秒，默认 600
+	WriteTimeout    int              `validate:"min=0"` // 写响应的超时时间 单位秒，为 0 时不限制
+	IdleTimeout     int              `validate:"min=0"` // keep-alive 空闲连接的超时时间 单位秒，为 0 时使用 ReadTimeout
+	MaxHeaderBytes  int              `validate:"min=0"` // 请求头最大字节数，默认 8192
+	MaxConnections  int              `validate:"min=0"` // 最大并发连接数，为 0 时不限制
 }
 
 const ServerConfigKey = "web.server"
 
+const unixPrefix = "unix:"
+
+// Network 返回监听的网络类型和地址
+func (s *ServerConfig) Network() (string, string) {
+	if path, ok := strings.CutPrefix(s.Listen, unixPrefix); ok {
+		return "unix", path
+	}
+	if len(s.Listen) > 0 {
+		return "tcp", s.Listen
+	}
+	return "tcp", net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+func (s *ServerConfig) Validate() error {
+	network, address := s.Network()
+	if network == "unix" {
+		if len(address) == 0 {
+			return errors.New("listen: unix socket path is empty")
+		}
+	} else if _, _, err := net.SplitHostPort(address); err != nil {
+		return errors.Errorf("listen: invalid address %q", address)
+	}
+	if len(s.SocketMode) > 0 {
+		if _, err := s.GetSocketMode(); err != nil {
+			return errors.Errorf("socketMode: %q is not an octal file mode", s.SocketMode)
+		}
+	}
+	return nil
+}
+
+func (s *ServerConfig) GetSocketMode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s.SocketMode, 8, 32)
+	return os.FileMode(mode), errors.WithStackIf(err)
+}
+
+func seconds(value int, defaultValue time.Duration) time.Duration {
+	if value <= 0 {
+		return defaultValue
+	}
+	return time.Duration(value) * time.Second
+}
+
+func (s *ServerConfig) GetReadTimeout() time.Duration {
+	return seconds(s.ReadTimeout, MaxReadTimeout)
+}
+
+func (s *ServerConfig) GetWriteTimeout() time.Duration {
+	return seconds(s.WriteTimeout, 0)
+}
+
+func (s *ServerConfig) GetIdleTimeout() time.Duration {
+	return seconds(s.IdleTimeout, 0)
+}
+
+func (s *ServerConfig) GetMaxHeaderBytes() int {
+	if s.MaxHeaderBytes <= 0 {
+		return MaxHeaderBytes
+	}
+	return s.MaxHeaderBytes
+}
+
 func (s *ServerConfig) SSLEnabled() bool {
 	return s.SSL != nil && s.SSL.Enabled
 }
 
+func (s *ServerConfig) GetShutdownTimeout() time.Duration {
+	return seconds(s.ShutdownTimeout, DefaultShutdownTimeout*time.Second)
+}
+
 func DefaultServerConfig() *ServerConfig {
 
 	return &ServerConfig{
@@ -50,6 +177,9 @@ func DefaultServerConfig() *ServerConfig {
 		SSL: &SSLConfig{
 			Enabled: false,
 		},
+		Security:        DefaultSecurityConfig(),
+		AccessLog:       DefaultAccessLogConfig(),
+		ShutdownTimeout: DefaultShutdownTimeout,
 	}
 }
 
@@ -58,35 +188,41 @@ type HttpServer struct {
 	engine        *gin.Engine
 	serverConfig  *ServerConfig
 	certManager   *CertManager
+	certificates  *certificates
 	memFileSystem *MemFileSystem
-}
-
-func defaultEngine() *gin.Engine {
-	engine := gin.Default()
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = false
-	config.AllowCredentials = true
-	config.AllowOriginFunc = func(origin string) bool {
-		return true
-	}
-	engine.Use(cors.New(config))
-	return engine
+	listener      net.Listener
+	cors          *pathPolicies[*CorsConfig]
+	security      *pathPolicies[*SecurityConfig]
+	panicHooks    []PanicHook
 }
 
 func NewHttpServer(serverConfig *ServerConfig, certManager *CertManager) *HttpServer {
-	if serverConfig.SSLEnabled() {
+	if serverConfig.SSLEnabled() && !serverConfig.SSL.SelfSigned() {
 		for _, host := range serverConfig.SSL.Hosts {
 			certManager.AddHost(host)
 		}
 		certManager.AddPort(serverConfig.Port)
 	}
-	engine := defaultEngine()
-	return &HttpServer{
-		engine:        engine,
+	httpServer := &HttpServer{
+		engine:        gin.New(),
 		serverConfig:  serverConfig,
 		certManager:   certManager,
 		memFileSystem: DefaultMemFileSystem(serverConfig),
+		cors:          &pathPolicies[*CorsConfig]{},
+		security:      &pathPolicies[*SecurityConfig]{},
+	}
+	if serverConfig.Cors != nil {
+		httpServer.Cors("/", serverConfig.Cors)
+	}
+	if serverConfig.Security != nil {
+		httpServer.Security("/", serverConfig.Security)
 	}
+	httpServer.engine.Use(requestIDHandle)
+	if serverConfig.AccessLog != nil {
+		httpServer.engine.Use(serverConfig.AccessLog.handle)
+	}
+	httpServer.engine.Use(httpServer.recoveryHandle, httpServer.securityHandle, httpServer.corsHandle)
+	return httpServer
 }
 func (httpServer *HttpServer) Port() int {
 	return httpServer.serverConfig.Port
@@ -112,7 +248,47 @@ func (httpServer *HttpServer) Any(relativePath string, handlers ...gin.HandlerFu
 func (httpServer *HttpServer) Use(handlers ...gin.HandlerFunc) {
 	httpServer.engine.Use(handlers...)
 }
+
+// ServeHTTP 直接由 gin 处理请求，不经过监听端口，可用于 httptest
+func (httpServer *HttpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	httpServer.engine.ServeHTTP(w, r)
+}
+
+// Group 创建路径前缀为 relativePath 的路由组，路由组内的中间件只作用于该前缀下的路由
+func (httpServer *HttpServer) Group(relativePath string, handlers ...gin.HandlerFunc) *gin.RouterGroup {
+	return httpServer.engine.Group(relativePath, handlers...)
+}
+
+// Run 监听端口并开始服务，阻塞直到服务关闭
 func (httpServer *HttpServer) Run() error {
+	return httpServer.Serve()
+}
+
+// Addr 监听地址，unix socket 为 unix:/path，平滑重启时按地址匹配继承的监听
+func (httpServer *HttpServer) Addr() string {
+	network, address := httpServer.serverConfig.Network()
+	if network == "unix" {
+		return unixPrefix + address
+	}
+	return address
+}
+
+// newServer 按 ServerConfig 设置超时时间和请求头大小，http 和 https 共用
+func (httpServer *HttpServer) newServer(handler http.Handler) *http.Server {
+	serverConfig := httpServer.serverConfig
+	return &http.Server{
+		Addr:              httpServer.Addr(),
+		Handler:           handler,
+		ReadHeaderTimeout: min(MaxReadHeaderTimeout, serverConfig.GetReadTimeout()),
+		ReadTimeout:       serverConfig.GetReadTimeout(),
+		WriteTimeout:      serverConfig.GetWriteTimeout(),
+		IdleTimeout:       serverConfig.GetIdleTimeout(),
+		MaxHeaderBytes:    serverConfig.GetMaxHeaderBytes(),
+	}
+}
+
+// Listen 只绑定监听端口，不处理请求，绑定失败时返回错误
+func (httpServer *HttpServer) Listen() error {
 	serverConfig := httpServer.serverConfig
 	engine := httpServer.engine
 	if serverConfig.Locations != nil {
@@ -151,20 +327,69 @@ func (httpServer *HttpServer) Run() error {
 
 	}
 	if httpServer.serverConfig.SSLEnabled() {
-		return httpServer.startTLS()
+		err := httpServer.initTLS()
+		if err != nil {
+			return err
+		}
+	} else {
+		httpServer.httpServer = httpServer.newServer(httpServer.engine)
+	}
+	network, address := serverConfig.Network()
+	listener, err := listen(network, address, httpServer.Addr())
+	if err != nil {
+		return err
+	}
+	if network == "unix" && len(serverConfig.SocketMode) > 0 {
+		mode, _ := serverConfig.GetSocketMode()
+		err = os.Chmod(address, mode)
+		if err != nil {
+			_ = listener.Close()
+			return errors.WithStackIf(err)
+		}
+	}
+	httpServer.listener = listener
+	return nil
+}
+
+// Serve 在 Listen 绑定的端口上处理请求，尚未 Listen 时先绑定端口，阻塞直到服务关闭
+func (httpServer *HttpServer) Serve() error {
+	if httpServer.listener == nil {
+		err := httpServer.Listen()
+		if err != nil {
+			return err
+		}
+	}
+	listener := httpServer.listener
+	if maxConnections := httpServer.serverConfig.MaxConnections; maxConnections > 0 {
+		listener = netutil.LimitListener(listener, maxConnections)
+	}
+	if httpServer.serverConfig.SSLEnabled() {
+		for _, host := range httpServer.serverConfig.SSL.Hosts {
+			log.Info("Start the service：", zap.String("address", "https://"+host+":"+strconv.Itoa(httpServer.serverConfig.Port)))
+		}
+		if len(httpServer.serverConfig.SSL.Hosts) == 0 {
+			log.Info("Start the service：", zap.String("address", httpServer.displayAddr("https")))
+		}
+		return serveError(httpServer.httpServer.ServeTLS(listener, "", ""))
+	}
+	log.Info("Start the service：", zap.String("address", httpServer.displayAddr("http")))
+	return serveError(httpServer.httpServer.Serve(listener))
+}
+
+// displayAddr 日志中显示的访问地址
+func (httpServer *HttpServer) displayAddr(scheme string) string {
+	network, address := httpServer.serverConfig.Network()
+	if network == "unix" {
+		return unixPrefix + address
 	}
-	httpServer.httpServer = &http.Server{
-		Addr:              ":" + strconv.Itoa(httpServer.serverConfig.Port),
-		Handler:           httpServer.engine,
-		ReadHeaderTimeout: MaxReadHeaderTimeout,
-		MaxHeaderBytes:    MaxHeaderBytes,
-		ReadTimeout:       MaxReadTimeout,
+	host, port, _ := net.SplitHostPort(address)
+	if len(host) == 0 || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
 	}
-	log.Info("Start the service：", zap.String("address", "http://127.0.0.1:"+strconv.Itoa(httpServer.serverConfig.Port)))
-	return errors.WithStackIf(httpServer.httpServer.ListenAndServe())
+	return scheme + "://" + net.JoinHostPort(host, port)
 }
 
-func (httpServer *HttpServer) startTLS() error {
+func (httpServer *HttpServer) initTLS() error {
 
 	certManager, err := httpServer.certManager.GetCertManager()
 	if err != nil {
@@ -174,29 +399,88 @@ func (httpServer *HttpServer) startTLS() error {
 	if httpServer.serverConfig.Port == 80 || httpServer.serverConfig.Port == 443 {
 		engine = certManager.HTTPHandler(engine)
 	}
-	httpServer.httpServer = &http.Server{
-		Addr:              ":" + strconv.Itoa(httpServer.serverConfig.Port),
-		Handler:           engine,
-		ReadHeaderTimeout: MaxReadHeaderTimeout,
-		MaxHeaderBytes:    MaxHeaderBytes,
-		ReadTimeout:       MaxReadTimeout,
-		TLSConfig: &tls.Config{
+	httpServer.httpServer = httpServer.newServer(engine)
+	ssl := httpServer.serverConfig.SSL
+	pairs := ssl.CertPairs()
+	if ssl.SelfSigned() {
+		pair, err := ensureSelfSigned(ssl.Hosts)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, pair)
+	}
+	if len(pairs) == 0 && len(ssl.ClientCAFile) == 0 {
+		httpServer.httpServer.TLSConfig = &tls.Config{
 			GetCertificate: certManager.GetCertificate,
 			NextProtos:     []string{http2.NextProtoTLS, "http/1.1"},
 			MinVersion:     tls.VersionTLS12,
-		},
+		}
+		return nil
+	}
+	// 证书文件中没有匹配 SNI 的证书时，配置了 Hosts 的域名继续使用 ACME 申请的证书
+	var fallback func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	if len(ssl.Hosts) > 0 && !ssl.SelfSigned() {
+		fallback = certManager.GetCertificate
+	}
+	certificates := newCertificates(ssl, pairs, fallback)
+	err = certificates.load()
+	if err != nil {
+		return err
+	}
+	certificates.watch()
+	httpServer.certificates = certificates
+	httpServer.httpServer.TLSConfig = certificates.tlsConfig()
+	return nil
+}
+
+// serveError 服务被 Shutdown 或 Close 后返回的 http.ErrServerClosed 不视为错误
+func serveError(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
 	}
-	for _, host := range httpServer.serverConfig.SSL.Hosts {
-		log.Info("Start the service：", zap.String("address", "https://"+host+":"+strconv.Itoa(httpServer.serverConfig.Port)))
+	return errors.WithStackIf(err)
+}
+
+func (httpServer *HttpServer) ShutdownTimeout() time.Duration {
+	return httpServer.serverConfig.GetShutdownTimeout()
+}
+
+// Shutdown 停止接收新连接，并等待进行中的请求处理完成，
+// ctx 超时后强制关闭剩余连接
+func (httpServer *HttpServer) Shutdown(ctx context.Context) error {
+	if httpServer.httpServer == nil {
+		return nil
+	}
+	err := httpServer.httpServer.Shutdown(ctx)
+	if httpServer.listener != nil {
+		_ = httpServer.listener.Close()
 	}
-	return errors.WithStackIf(httpServer.httpServer.ListenAndServeTLS("", ""))
+	httpServer.closeCertificates()
+	if err != nil {
+		log.Warn("Graceful shutdown timed out, closing remaining connections", zap.Int("port", httpServer.Port()), zap.Error(err))
+		return errors.Combine(errors.WithStackIf(err), httpServer.Close())
+	}
+	return nil
 }
 
 func (httpServer *HttpServer) Close() error {
 	if httpServer.httpServer == nil {
 		return nil
 	}
-	return httpServer.httpServer.Close()
+	err := httpServer.httpServer.Close()
+	if httpServer.listener != nil {
+		// 已 Listen 但尚未 Serve 时，监听端口需要单独关闭
+		_ = httpServer.listener.Close()
+	}
+	httpServer.closeCertificates()
+	return err
+}
+
+// closeCertificates 停止监听证书文件
+func (httpServer *HttpServer) closeCertificates() {
+	if httpServer.certificates != nil {
+		_ = httpServer.certificates.Close()
+	}
 }
 
 type CertManager struct {
@@ -242,7 +526,7 @@ func (cm *CertManager) GetCertManager() (*autocert.Manager, error) {
 	if cm.certManager != nil {
 		return cm.certManager, nil
 	}
-	certsPath := "certs"
+	certsPath := CertsDir
 	err := util.CreateDirIfNoExists(certsPath)
 	if err != nil {
 		return nil, err
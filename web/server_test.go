@@ -0,0 +1,154 @@
+package web
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestShutdownDrainsRequests(t *testing.T) {
+	serverConfig := DefaultServerConfig()
+	serverConfig.Port = freePort(t)
+	httpServer := NewHttpServer(serverConfig, NewCertManager())
+	started := make(chan struct{})
+	httpServer.GET("/slow", func(context *gin.Context) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		context.String(http.StatusOK, "done")
+	})
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- httpServer.Run()
+	}()
+	url := "http://127.0.0.1:" + strconv.Itoa(serverConfig.Port) + "/slow"
+	status := make(chan int, 1)
+	go func() {
+		for i := 0; i < 50; i++ {
+			response, err := http.Get(url)
+			if err == nil {
+				response.Body.Close()
+				status <- response.StatusCode
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		status <- 0
+	}()
+	<-started
+	err := httpServer.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := <-status; code != http.StatusOK {
+		t.Fatalf("in-flight request was dropped, status %d", code)
+	}
+	if err := <-runErr; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListenerFile(t *testing.T) {
+	serverConfig := DefaultServerConfig()
+	serverConfig.Port = freePort(t)
+	httpServer := NewHttpServer(serverConfig, NewCertManager())
+	err := httpServer.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := httpServer.ListenerFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = httpServer.Close()
+	listener, err := net.FileListener(file)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	inherited.once.Do(func() {})
+	inherited.lock.Lock()
+	inherited.listeners[httpServer.Addr()] = listener
+	inherited.lock.Unlock()
+
+	next := NewHttpServer(serverConfig, NewCertManager())
+	next.GET("/", func(context *gin.Context) {
+		context.String(http.StatusOK, "inherited")
+	})
+	go next.Run()
+	defer next.Close()
+	response, err := http.Get("http://127.0.0.1:" + strconv.Itoa(serverConfig.Port) + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", response.StatusCode)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	serverConfig := DefaultServerConfig()
+	serverConfig.Listen = "unix:" + path
+	serverConfig.SocketMode = "0600"
+	serverConfig.WriteTimeout = 5
+	serverConfig.MaxConnections = 1
+	if err := serverConfig.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := NewHttpServer(serverConfig, NewCertManager())
+	httpServer.GET("/", func(context *gin.Context) {
+		context.String(http.StatusOK, "unix")
+	})
+	err := httpServer.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go httpServer.Serve()
+	defer httpServer.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected socket mode %v", info.Mode())
+	}
+	if httpServer.httpServer.WriteTimeout != 5*time.Second {
+		t.Fatalf("unexpected write timeout %v", httpServer.httpServer.WriteTimeout)
+	}
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return net.Dial("unix", path)
+		},
+	}}
+	response, err := client.Get("http://unix/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", response.StatusCode)
+	}
+
+	serverConfig.Listen = "unix:"
+	if serverConfig.Validate() == nil {
+		t.Fatal("empty socket path should be rejected")
+	}
+}
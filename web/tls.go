@@ -0,0 +1,216 @@
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/chuccp/go-web-frame/log"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+)
+
+// 客户端证书校验方式，对应 tls.ClientAuthType
+const (
+	ClientAuthNone             = "none"
+	ClientAuthRequest          = "request"
+	ClientAuthRequire          = "require"
+	ClientAuthVerifyIfGiven    = "verify-if-given"
+	ClientAuthRequireAndVerify = "require-and-verify"
+)
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	ClientAuthNone:             tls.NoClientCert,
+	ClientAuthRequest:          tls.RequestClientCert,
+	ClientAuthRequire:          tls.RequireAnyClientCert,
+	ClientAuthVerifyIfGiven:    tls.VerifyClientCertIfGiven,
+	ClientAuthRequireAndVerify: tls.RequireAndVerifyClientCert,
+}
+
+// certReloadDelay 证书文件连续变化时合并为一次重新加载
+const certReloadDelay = 100 * time.Millisecond
+
+type CertConfig struct {
+	CertFile string `validate:"required"`
+	KeyFile  string `validate:"required"`
+}
+
+// certificates 从文件加载的证书和客户端 CA，文件变化后重新加载，加载失败时继续使用原来的证书
+type certificates struct {
+	pairs        []*CertConfig
+	clientCAFile string
+	clientAuth   tls.ClientAuthType
+	fallback     func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	lock         sync.RWMutex
+	certs        []*tls.Certificate
+	clientCAs    *x509.CertPool
+	watcher      *fsnotify.Watcher
+}
+
+func newCertificates(ssl *SSLConfig, pairs []*CertConfig, fallback func(*tls.ClientHelloInfo) (*tls.Certificate, error)) *certificates {
+	return &certificates{
+		pairs:        pairs,
+		clientCAFile: ssl.ClientCAFile,
+		clientAuth:   ssl.GetClientAuth(),
+		fallback:     fallback,
+	}
+}
+
+func (c *certificates) load() error {
+	certs := make([]*tls.Certificate, 0, len(c.pairs))
+	for _, pair := range c.pairs {
+		cert, err := tls.LoadX509KeyPair(pair.CertFile, pair.KeyFile)
+		if err != nil {
+			return errors.WrapIff(err, "load certificate %s", pair.CertFile)
+		}
+		certs = append(certs, &cert)
+	}
+	var clientCAs *x509.CertPool
+	if len(c.clientCAFile) > 0 {
+		data, err := os.ReadFile(c.clientCAFile)
+		if err != nil {
+			return errors.WrapIff(err, "read client CA %s", c.clientCAFile)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return errors.Errorf("no certificate found in client CA %s", c.clientCAFile)
+		}
+	}
+	c.lock.Lock()
+	c.certs = certs
+	c.clientCAs = clientCAs
+	c.lock.Unlock()
+	return nil
+}
+
+// GetCertificate 按 SNI 选择证书，没有匹配的证书时使用 fallback，没有 fallback 时使用第一个证书
+func (c *certificates) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.lock.RLock()
+	certs := c.certs
+	c.lock.RUnlock()
+	for _, cert := range certs {
+		if hello.SupportsCertificate(cert) == nil {
+			return cert, nil
+		}
+	}
+	if c.fallback != nil {
+		return c.fallback(hello)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate")
+	}
+	return certs[0], nil
+}
+
+// tlsConfig 每次握手使用当前的客户端 CA，证书更新后新的连接立即生效
+func (c *certificates) tlsConfig() *tls.Config {
+	config := &tls.Config{
+		GetCertificate: c.GetCertificate,
+		NextProtos:     []string{http2.NextProtoTLS, "http/1.1"},
+		MinVersion:     tls.VersionTLS12,
+		ClientAuth:     c.clientAuth,
+	}
+	if len(c.clientCAFile) == 0 {
+		return config
+	}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c.lock.RLock()
+		clientCAs := c.clientCAs
+		c.lock.RUnlock()
+		return &tls.Config{
+			GetCertificate: c.GetCertificate,
+			NextProtos:     config.NextProtos,
+			MinVersion:     config.MinVersion,
+			ClientAuth:     c.clientAuth,
+			ClientCAs:      clientCAs,
+		}, nil
+	}
+	return config
+}
+
+func (c *certificates) files() []string {
+	files := make([]string, 0, len(c.pairs)*2+1)
+	for _, pair := range c.pairs {
+		files = append(files, pair.CertFile, pair.KeyFile)
+	}
+	if len(c.clientCAFile) > 0 {
+		files = append(files, c.clientCAFile)
+	}
+	return files
+}
+
+// watch 监听证书所在的目录，目录中任意文件变化都重新加载，以支持通过符号链接整体替换证书
+func (c *certificates) watch() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warn("Failed to watch the certificates", zap.Error(err))
+		return
+	}
+	dirs := make([]string, 0)
+	for _, file := range c.files() {
+		dir := filepath.Dir(file)
+		if slices.Contains(dirs, dir) {
+			continue
+		}
+		dirs = append(dirs, dir)
+		if err := watcher.Add(dir); err != nil {
+			log.Warn("Failed to watch the certificates", zap.String("dir", dir), zap.Error(err))
+		}
+	}
+	c.lock.Lock()
+	c.watcher = watcher
+	c.lock.Unlock()
+	go c.watchLoop(watcher)
+}
+
+func (c *certificates) watchLoop(watcher *fsnotify.Watcher) {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(certReloadDelay, func() {
+				if err := c.load(); err != nil {
+					log.Error("Failed to reload the certificates", zap.Error(err))
+					return
+				}
+				log.Info("Reloaded the certificates", zap.Strings("files", c.files()))
+			})
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warn("certificate watcher", zap.Error(err))
+		}
+	}
+}
+
+func (c *certificates) Close() error {
+	c.lock.Lock()
+	watcher := c.watcher
+	c.watcher = nil
+	c.lock.Unlock()
+	if watcher == nil {
+		return nil
+	}
+	return errors.WithStackIf(watcher.Close())
+}
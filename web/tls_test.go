@@ -0,0 +1,199 @@
+package web
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, commonName string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{commonName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, certFile string, keyFile string) {
+	err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyFile == "" {
+		return
+	}
+	der, _ := x509.MarshalECPrivateKey(c.key)
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test ca", nil, x509.ExtKeyUsageAny)
+	ca.write(t, filepath.Join(dir, "ca.pem"), "")
+	newTestCert(t, "localhost", ca, x509.ExtKeyUsageServerAuth).write(t, filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+
+	serverConfig := DefaultServerConfig()
+	serverConfig.Port = freePort(t)
+	serverConfig.SSL = &SSLConfig{
+		Enabled:      true,
+		CertFile:     filepath.Join(dir, "server.pem"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+	}
+	if err := serverConfig.SSL.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := NewHttpServer(serverConfig, NewCertManager())
+	httpServer.GET("/", func(context *gin.Context) {
+		cert := NewRequest(context, nil).ClientCertificate()
+		context.String(http.StatusOK, cert.Subject.CommonName)
+	})
+	if err := httpServer.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	go httpServer.Serve()
+	defer httpServer.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	url := "https://localhost:" + strconv.Itoa(serverConfig.Port) + "/"
+	get := func(clientCerts ...tls.Certificate) (*http.Response, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: clientCerts},
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(serverConfig.Port))
+			},
+		}}
+		return client.Get(url)
+	}
+
+	client := newTestCert(t, "client-a", ca, x509.ExtKeyUsageClientAuth)
+	response, err := get(client.tlsCertificate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := make([]byte, 64)
+	n, _ := response.Body.Read(body)
+	response.Body.Close()
+	if string(body[:n]) != "client-a" {
+		t.Fatalf("unexpected client certificate %q", body[:n])
+	}
+	if response.TLS.PeerCertificates[0].Subject.CommonName != "localhost" {
+		t.Fatal("unexpected server certificate")
+	}
+	if _, err := get(); err == nil {
+		t.Fatal("request without client certificate should be rejected")
+	}
+
+	renewed := newTestCert(t, "localhost", ca, x509.ExtKeyUsageServerAuth)
+	renewed.write(t, filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		response, err := get(client.tlsCertificate())
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.TLS.PeerCertificates[0].SerialNumber.Cmp(renewed.cert.SerialNumber) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("certificate was not reloaded")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestSelfSigned(t *testing.T) {
+	t.Chdir(t.TempDir())
+	serverConfig := DefaultServerConfig()
+	serverConfig.Port = freePort(t)
+	serverConfig.SSL = &SSLConfig{Enabled: true, Mode: SSLModeSelfSigned, Hosts: []string{"dev.example.com"}}
+	httpServer := NewHttpServer(serverConfig, NewCertManager())
+	httpServer.GET("/", func(context *gin.Context) {
+		context.String(http.StatusOK, "ok")
+	})
+	if err := httpServer.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	go httpServer.Serve()
+	defer httpServer.Close()
+
+	data, err := os.ReadFile(LocalCAFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(data)
+	for _, serverName := range []string{"localhost", "127.0.0.1", "dev.example.com"} {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: serverName},
+		}}
+		response, err := client.Get("https://127.0.0.1:" + strconv.Itoa(serverConfig.Port) + "/")
+		if err != nil {
+			t.Fatalf("%s: %v", serverName, err)
+		}
+		response.Body.Close()
+	}
+
+	first, err := ensureSelfSigned(serverConfig.SSL.Hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(first.CertFile)
+	second, _ := ensureSelfSigned(serverConfig.SSL.Hosts)
+	after, _ := os.ReadFile(second.CertFile)
+	if string(before) != string(after) {
+		t.Fatal("the cached certificate should be reused")
+	}
+}
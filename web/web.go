@@ -8,8 +8,10 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/chuccp/go-web-frame/log"
 	"github.com/chuccp/go-web-frame/util"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 type HandlersChain []HandlerFunc
@@ -99,49 +101,69 @@ func AuthRawChecks(handlers ...HandlerRawFunc) []HandlerRawFunc {
 func toGinHandlerFunc(digestAuth *DigestAuth, handler HandlerFunc) gin.HandlerFunc {
 	handlerFunc := func(context *gin.Context) {
 		value, err := handler(NewRequest(context, digestAuth))
-		if err != nil {
-			err0 := Errors(value, err)
-			context.JSON(err0.Code, err0)
-			context.Abort()
-		} else {
-			if value != nil {
-				switch t := value.(type) {
-				case *Message:
-					if t.Code == http.StatusMovedPermanently {
-						context.Redirect(http.StatusMovedPermanently, t.Data.(string))
-						context.Abort()
-						return
-					}
-					context.JSON(t.Code, value)
-				case string:
-					_, err2 := context.Writer.Write([]byte(t))
-					if err2 != nil {
-						context.Abort()
-						return
-					}
-				case *File:
-					if len(t.FileName) == 0 {
-						_, filename := path.Split(t.Path)
-						t.FileName = filename
-					}
-					if util.IsNotBlank(t.Suffix) && !strings.HasSuffix(t.FileName, t.Suffix) {
-						if !strings.HasPrefix(t.Suffix, ".") {
-							t.Suffix = "." + t.Suffix
-						}
-						t.FileName = t.FileName + t.Suffix
-					}
-					context.FileAttachment(t.Path, t.FileName)
-				case *os.File:
-					context.FileAttachment(t.Name(), t.Name())
+		writeResponse(context, value, err)
+	}
+	return handlerFunc
+}
+
+// ToGinMiddleware 中间件返回非 nil 的值或错误时中止请求，并按与 HandlerFunc 相同的方式响应；
+// 中间件可以调用 Request.Next 在后续处理前后执行代码，此时响应已写出，返回值只记录日志
+func ToGinMiddleware(digestAuth *DigestAuth, middleware HandlerFunc) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		value, err := middleware(NewRequest(context, digestAuth))
+		if value == nil && err == nil {
+			return
+		}
+		if context.Writer.Written() {
+			log.Ctx(context.Request.Context()).Warn("The middleware returned after the response was written", zap.String("path", context.FullPath()), zap.Any("value", value), zap.Error(err))
+			return
+		}
+		context.Abort()
+		writeResponse(context, value, err)
+	}
+}
 
-				default:
-					context.JSON(200, Data(value))
+func writeResponse(context *gin.Context, value any, err error) {
+	if err != nil {
+		err0 := Errors(value, err)
+		context.JSON(err0.Code, err0)
+		context.Abort()
+	} else {
+		if value != nil {
+			switch t := value.(type) {
+			case *Message:
+				if t.Code == http.StatusMovedPermanently {
+					context.Redirect(http.StatusMovedPermanently, t.Data.(string))
+					context.Abort()
+					return
+				}
+				context.JSON(t.Code, value)
+			case string:
+				_, err2 := context.Writer.Write([]byte(t))
+				if err2 != nil {
+					context.Abort()
+					return
+				}
+			case *File:
+				if len(t.FileName) == 0 {
+					_, filename := path.Split(t.Path)
+					t.FileName = filename
 				}
+				if util.IsNotBlank(t.Suffix) && !strings.HasSuffix(t.FileName, t.Suffix) {
+					if !strings.HasPrefix(t.Suffix, ".") {
+						t.Suffix = "." + t.Suffix
+					}
+					t.FileName = t.FileName + t.Suffix
+				}
+				context.FileAttachment(t.Path, t.FileName)
+			case *os.File:
+				context.FileAttachment(t.Name(), t.Name())
+
+			default:
+				context.JSON(200, Data(value))
 			}
 		}
-
 	}
-	return handlerFunc
 }
 func toGinHandlerRawFunc(digestAuth *DigestAuth, handler HandlerRawFunc) gin.HandlerFunc {
 	handlerFunc := func(context *gin.Context) {
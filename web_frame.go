@@ -1,13 +1,23 @@
 package wf
 
 import (
+	"context"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
 	"sync"
+	"syscall"
+	"time"
 
 	"emperror.dev/errors"
 	config2 "github.com/chuccp/go-web-frame/config"
 	"github.com/chuccp/go-web-frame/core"
 	db2 "github.com/chuccp/go-web-frame/db"
 	"github.com/chuccp/go-web-frame/log"
+	"github.com/chuccp/go-web-frame/util"
 	"github.com/chuccp/go-web-frame/web"
 	"github.com/gin-gonic/gin"
 	"github.com/kardianos/service"
@@ -33,6 +43,38 @@ func GetRunner[T core.IRunner](c *core.Context) T {
 	return core.GetRunner[T](c)
 }
 
+func GetServiceNamed[T core.IService](c *core.Context, name string) T {
+	return core.GetServiceNamed[T](c, name)
+}
+
+func GetModelNamed[T core.IModel](c *core.Context, name string) T {
+	return core.GetModelNamed[T](c, name)
+}
+
+func GetComponentNamed[T core.IComponent](c *core.Context, name string) T {
+	return core.GetComponentNamed[T](c, name)
+}
+
+func GetRunnerNamed[T core.IRunner](c *core.Context, name string) T {
+	return core.GetRunnerNamed[T](c, name)
+}
+
+func FindService[T core.IService](c *core.Context) (T, error) {
+	return core.FindService[T](c)
+}
+
+func FindModel[T core.IModel](c *core.Context) (T, error) {
+	return core.FindModel[T](c)
+}
+
+func FindComponent[T core.IComponent](c *core.Context) (T, error) {
+	return core.FindComponent[T](c)
+}
+
+func FindRunner[T core.IRunner](c *core.Context) (T, error) {
+	return core.FindRunner[T](c)
+}
+
 func UnmarshalConfig[T any](key string, c *core.Context) T {
 	return core.UnmarshalConfig[T](key, c)
 }
@@ -44,9 +86,11 @@ type WebFrame struct {
 	config            config2.IConfig
 	models            []core.IModel
 	services          []core.IService
+	namedServices     map[string]core.IService
 	rests             []core.IRest
 	runners           []core.IRunner
-	middlewareFunc    []core.MiddlewareFunc
+	middleware        []core.Middleware
+	panicHooks        []web.PanicHook
 	authentication    web.Authentication
 	db                *gorm.DB
 	schedule          *core.Schedule
@@ -54,6 +98,16 @@ type WebFrame struct {
 	lock              *sync.Mutex
 	defaultModelGroup core.IModelGroup
 	isClose           bool
+	lifecycle         []any
+	lifecycleRunners  map[int]int // lifecycle 中 runner 的下标对应 runners 中的下标
+	lifecycleConfig   *core.LifecycleConfig
+	ready             chan struct{}
+	health            *core.Health
+	context           *core.Context
+	serverConfig      *web.ServerConfig
+	commands          []*Command
+	version           string
+	output            io.Writer
 }
 
 func New(config config2.IConfig) *WebFrame {
@@ -61,6 +115,7 @@ func New(config config2.IConfig) *WebFrame {
 	w := &WebFrame{
 		models:            make([]core.IModel, 0),
 		services:          make([]core.IService, 0),
+		namedServices:     make(map[string]core.IService),
 		restGroups:        make([]*core.RestGroup, 0),
 		modelGroup:        make([]core.IModelGroup, 0),
 		rests:             make([]core.IRest, 0),
@@ -71,6 +126,10 @@ func New(config config2.IConfig) *WebFrame {
 		lock:              new(sync.Mutex),
 		defaultModelGroup: core.DefaultModelGroup(),
 		isClose:           false,
+		lifecycleConfig:   core.DefaultLifecycleConfig(),
+		ready:             make(chan struct{}),
+		health:            core.NewHealth(core.DefaultHealthConfig()),
+		output:            os.Stdout,
 	}
 	return w
 }
@@ -92,35 +151,77 @@ func (w *WebFrame) addService(service core.IService) {
 func (w *WebFrame) AddService(service ...core.IService) {
 	w.services = append(w.services, service...)
 }
+
+// AddNamedService 按名称注册 service，通过 GetServiceNamed 或 wf:"inject=name" 获取
+func (w *WebFrame) AddNamedService(name string, service core.IService) {
+	w.namedServices[name] = service
+}
 func (w *WebFrame) GetRestGroup(serverConfig *web.ServerConfig) *core.RestGroup {
 	groupGroup := core.NewRestGroup(serverConfig)
 	w.restGroups = append(w.restGroups, groupGroup)
 	return groupGroup
 }
 func (w *WebFrame) AddMiddleware(middlewareFunc ...core.MiddlewareFunc) {
-	w.middlewareFunc = append(w.middlewareFunc, middlewareFunc...)
+	for _, f := range middlewareFunc {
+		w.middleware = append(w.middleware, f.Middleware())
+	}
+}
+
+// OnPanic 添加处理函数 panic 后调用的 hook，用于上报错误，响应仍为 500 的 web.Message
+func (w *WebFrame) OnPanic(hook ...web.PanicHook) {
+	w.panicHooks = append(w.panicHooks, hook...)
+}
+
+// Use 为默认路由组添加可中止请求的中间件，与 AddMiddleware 添加的中间件按添加顺序执行
+func (w *WebFrame) Use(middleware ...core.Middleware) {
+	w.middleware = append(w.middleware, middleware...)
 }
 
+// Close 按顺序关闭：调用 OnStopping，停止接收新请求并等待进行中的请求完成，停止定时任务并等待执行中的任务，
+// 销毁 runner，最后销毁组件
 func (w *WebFrame) Close() error {
 	w.lock.Lock()
 	defer w.lock.Unlock()
+	if w.isClose {
+		return nil
+	}
 	w.isClose = true
+	w.health.SetReady(false)
 	errs := make([]error, 0)
-	err := w.server.Destroy()
-	errs = append(errs, err)
-	err = log.Sync()
-	errs = append(errs, err)
-	err = w.schedule.Destroy()
+	stoppingCtx, cancel := context.WithTimeout(context.Background(), w.lifecycleConfig.GetStopTimeout())
+	errs = append(errs, core.OnStopping(stoppingCtx, w.lifecycle...))
+	cancel()
+	timeout := web.DefaultShutdownTimeout * time.Second
+	if w.server != nil {
+		timeout = w.server.ShutdownTimeout()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := w.server.Shutdown(ctx)
+		cancel()
+		errs = append(errs, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	err := w.schedule.Shutdown(ctx)
+	cancel()
 	errs = append(errs, err)
-	for _, component := range w.component {
-		err = component.Destroy()
+	if w.server != nil {
+		err = w.server.Destroy()
 		errs = append(errs, err)
 	}
-	if len(errs) == 0 {
-		return nil
+	for i := len(w.component) - 1; i >= 0; i-- {
+		err = w.component[i].Destroy()
+		errs = append(errs, err)
+	}
+	if closer, ok := w.config.(io.Closer); ok {
+		errs = append(errs, closer.Close())
 	}
+	err = log.Sync()
+	errs = append(errs, err)
 	return errors.Combine(errs...)
 }
+
+// Start 启动服务并阻塞，所有端口绑定成功后调用 OnStarted 并关闭 Ready 通道，
+// 收到 SIGINT/SIGTERM 信号后执行 Close 优雅关闭；Linux 下收到 SIGHUP/SIGUSR2 时平滑重启，
+// 新进程继承所有监听端口并就绪后，当前进程处理完进行中的请求再退出
 func (w *WebFrame) Start() error {
 	err := w.init()
 	if err != nil {
@@ -129,30 +230,135 @@ func (w *WebFrame) Start() error {
 	if w.isClose {
 		return errors.New("The service has been closed")
 	}
-	return w.server.Run()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	err = w.server.Listen()
+	if err != nil {
+		return errors.Combine(err, w.Close())
+	}
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- w.server.Run()
+	}()
+	err = w.started(ctx)
+	if err != nil {
+		return err
+	}
+	restart := make(chan os.Signal, 1)
+	if len(restartSignals) > 0 {
+		signal.Notify(restart, restartSignals...)
+		defer signal.Stop(restart)
+	}
+	for {
+		select {
+		case err := <-runErr:
+			return err
+		case <-ctx.Done():
+			log.Info("Received the stop signal, shutting down the service")
+			return w.Close()
+		case <-restart:
+			log.Info("Received the restart signal, starting the new process")
+			err := w.restart(ctx)
+			if err != nil {
+				log.Error("Failed to restart, the service keeps running", zap.Error(err))
+				continue
+			}
+			log.Info("The new process is ready, shutting down the old process")
+			return w.Close()
+		}
+	}
+}
+
+// started 调用 OnStarted，成功后标记为就绪并关闭 Ready 通道，失败时关闭服务
+func (w *WebFrame) started(ctx context.Context) error {
+	startedCtx, cancel := context.WithTimeout(ctx, w.lifecycleConfig.GetStartTimeout())
+	err := core.OnStarted(startedCtx, w.lifecycle...)
+	cancel()
+	if err != nil {
+		log.Error("Failed to start the service", zap.Error(err))
+		return errors.Combine(err, w.Close())
+	}
+	w.health.SetReady(true)
+	close(w.ready)
+	notifyRestarted()
+	return nil
+}
+
+// Prepare 与 Start 一样初始化并调用 OnStarted，但不绑定端口、不运行 runner，
+// 请求通过 Handler 直接处理，用于测试
+func (w *WebFrame) Prepare() error {
+	err := w.init()
+	if err != nil {
+		return err
+	}
+	if w.isClose {
+		return errors.New("The service has been closed")
+	}
+	return w.started(context.Background())
 }
-func (w *WebFrame) init() error {
-	w.lock.Lock()
-	defer w.lock.Unlock()
+
+// Handler 返回端口对应的 http 处理器，未指定端口时为 web.server 配置的端口，需要在 Prepare 或 Start 之后调用
+func (w *WebFrame) Handler(port ...int) http.Handler {
+	if w.server == nil {
+		return nil
+	}
+	p := web.DefaultServerConfig().Port
+	if w.serverConfig != nil {
+		p = w.serverConfig.Port
+	}
+	if len(port) > 0 {
+		p = port[0]
+	}
+	if httpServer := w.server.HttpServer(p); httpServer != nil {
+		return httpServer
+	}
+	return nil
+}
+
+// Context 返回初始化后的 core.Context，可用于获取 service、model 和组件
+func (w *WebFrame) Context() *core.Context {
+	return w.context
+}
+
+// initModels 校验配置，初始化日志、组件和 model，migrate 命令只需要初始化到这一步
+func (w *WebFrame) initModels() (*core.Context, error) {
 	gin.SetMode(gin.ReleaseMode)
+	err := w.validateConfig()
+	if err != nil {
+		log.Error("Invalid configuration", zap.Error(err))
+		return nil, err
+	}
 	var logConfig log.Config
-	err := w.config.Unmarshal(logConfig.Key(), &logConfig)
+	err = w.config.Unmarshal(logConfig.Key(), &logConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	log.InitLogger(&logConfig)
+	err = w.config.Unmarshal(w.lifecycleConfig.Key(), w.lifecycleConfig)
+	if err != nil {
+		return nil, err
+	}
 
+	w.component, err = core.SortByDependency(w.component)
+	if err != nil {
+		log.Error("Failed to sort the components", zap.Error(err))
+		return nil, err
+	}
 	for _, component := range w.component {
 		err := errors.WithStackIf(component.Init(w.config))
 		if err != nil {
 			log.Error("Failed to initialize the component", zap.Error(err))
-			return err
+			return nil, err
 		}
 	}
 
 	coreContext := core.NewContext(w.config, w.schedule, w.defaultModelGroup)
+	w.context = coreContext
 	coreContext.AddComponent(w.component...)
 	coreContext.AddService(w.services...)
+	for name, service := range w.namedServices {
+		coreContext.AddNamedService(name, service)
+	}
 	coreContext.AddRunner(w.runners...)
 
 	if len(w.models) > 0 {
@@ -163,12 +369,12 @@ func (w *WebFrame) init() error {
 		db, err := db2.CreateDB(w.config)
 		if err != nil {
 			log.Error("Failed to initialize the database", zap.Error(err))
-			return err
+			return nil, err
 		}
 		err = w.defaultModelGroup.SwitchDB(db, coreContext)
 		if err != nil {
 			log.Error("Failed to switch the database", zap.Error(err))
-			return err
+			return nil, err
 		}
 	}
 
@@ -178,47 +384,249 @@ func (w *WebFrame) init() error {
 			coreContext.AddModel(modelGroup.GetModel()...)
 			err := modelGroup.Init(coreContext)
 			if err != nil {
-				return errors.WithStackIf(err)
+				return nil, errors.WithStackIf(err)
 			}
 		}
 	}
+	return coreContext, nil
+}
 
-	for _, iService := range w.services {
-		err := iService.Init(coreContext)
-		if err != nil {
-			return errors.WithStackIf(err)
-		}
+func (w *WebFrame) init() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	coreContext, err := w.initModels()
+	if err != nil {
+		return err
+	}
+
+	err = w.initServices(coreContext)
+	if err != nil {
+		return err
 	}
 
+	var serverConfig = web.DefaultServerConfig()
+	err = w.config.Unmarshal(web.ServerConfigKey, &serverConfig)
+	if err != nil {
+		return err
+	}
+	w.serverConfig = serverConfig
 	if w.config.HasKey(web.ServerConfigKey) || len(w.restGroups) == 0 || len(w.rests) > 0 {
-		var serverConfig = web.DefaultServerConfig()
-		err = w.config.Unmarshal(web.ServerConfigKey, &serverConfig)
-		if err != nil {
-			return err
-		}
 		rootGroup := core.NewRestGroup(serverConfig)
 		rootGroup.AddRest(w.rests...)
 		rootGroup.Authentication(w.authentication)
-		rootGroup.AddMiddlewares(w.middlewareFunc...)
+		rootGroup.Use(w.middleware...)
 		w.restGroups = append(w.restGroups, rootGroup)
 	}
+	err = w.config.Unmarshal(w.health.Config().Key(), w.health.Config())
+	if err != nil {
+		return err
+	}
+	if w.health.Config().Enable {
+		healthServerConfig := serverConfig
+		if w.health.Config().Port > 0 {
+			healthServerConfig = web.DefaultServerConfig()
+			healthServerConfig.Port = w.health.Config().Port
+			healthServerConfig.Host = w.health.Config().Host
+			healthServerConfig.AccessLog.Exclude = []string{w.health.Config().LivenessPath, w.health.Config().ReadinessPath}
+		}
+		w.restGroups = append(w.restGroups, core.NewRestGroup(healthServerConfig).AddRest(w.health))
+	}
 	w.server = core.NewServer(w.restGroups, w.runners)
+	w.server.OnPanic(w.panicHooks...)
 	err = w.server.Init(coreContext)
 	if err != nil {
 		return err
 	}
+	for _, rest := range w.server.Rests() {
+		if rest != w.health {
+			w.lifecycle = append(w.lifecycle, rest)
+		}
+	}
+	w.addHealthChecks()
 	err = w.schedule.Init(w.config)
 	if err != nil {
 		log.Error("Failed to initialize the scheduled task", zap.Error(err))
 		return err
 	}
+	w.subscribeConfig()
+	return nil
+}
+
+// configValidators 框架自身的配置以及实现了 IValidateConfig 的组件
+func (w *WebFrame) configValidators() []config2.ValidateFunc {
+	validators := []config2.ValidateFunc{
+		unmarshalValidator(func() any { return &log.Config{} }, (&log.Config{}).Key()),
+		unmarshalValidator(func() any { return core.DefaultLifecycleConfig() }, w.lifecycleConfig.Key()),
+		unmarshalValidator(func() any { return core.DefaultHealthConfig() }, w.health.Config().Key()),
+		unmarshalValidator(func() any { return &core.ScheduleConfig{} }, (&core.ScheduleConfig{}).Key()),
+		unmarshalValidator(func() any { return web.DefaultServerConfig() }, web.ServerConfigKey),
+		unmarshalValidator(func() any { return &DaemonConfig{} }, (&DaemonConfig{}).Key()),
+		func(config config2.IConfig) error {
+			if !config.HasKey(db2.ConfigKey) {
+				return nil
+			}
+			dbConfig, err := db2.LoadConfig(config)
+			if err != nil {
+				return err
+			}
+			return config2.CheckUnknownKeys(config, db2.ConfigKey, dbConfig, "type")
+		},
+	}
+	for _, component := range w.component {
+		if validator, ok := component.(core.IValidateConfig); ok {
+			validators = append(validators, validator.ValidateConfig)
+		}
+	}
+	return validators
+}
+
+// unmarshalValidator 校验框架自身的配置，除 Unmarshal 的校验外还检查未知的配置项
+func unmarshalValidator(newValue func() any, key string) config2.ValidateFunc {
+	return func(config config2.IConfig) error {
+		v := newValue()
+		return errors.Combine(config.Unmarshal(key, v), config2.CheckUnknownKeys(config, key, v))
+	}
+}
+
+// validateConfig 初始化之前校验所有配置，错误合并后一起返回，校验同时用于配置重新加载
+func (w *WebFrame) validateConfig() error {
+	validators := w.configValidators()
+	errs := make([]error, 0)
+	for _, validate := range validators {
+		errs = append(errs, validate(w.config))
+	}
+	err := errors.Combine(errs...)
+	if err != nil {
+		return errors.WrapIf(err, "invalid config")
+	}
+	for _, validate := range validators {
+		w.config.AddValidator(validate)
+	}
+	return nil
+}
+
+// subscribeConfig 配置文件变化后修改日志级别，并调用实现了 IReload 的组件、service、runner 和定时任务
+func (w *WebFrame) subscribeConfig() {
+	w.config.Subscribe("web.log.level", func(old, new any) {
+		var logConfig log.Config
+		err := w.config.Unmarshal(logConfig.Key(), &logConfig)
+		if err == nil {
+			err = log.SetLevel(logConfig.Level)
+		}
+		if err != nil {
+			log.Error("Failed to reload the log level", zap.Error(err))
+		}
+	})
+	w.config.Subscribe("", func(old, new any) {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		if w.isClose {
+			return
+		}
+		items := append(slices.Clone(w.lifecycle), w.schedule)
+		for _, item := range items {
+			if reload, ok := item.(core.IReload); ok {
+				err := reload.Reload(w.config)
+				if err != nil {
+					log.Error("Failed to reload", zap.String("name", util.GetStructFullName(item)), zap.Error(err))
+				}
+			}
+		}
+	})
+}
+
+// initServices 将 service 与 runner 合并后按依赖顺序初始化，runner 按排序后的顺序运行和销毁
+func (w *WebFrame) initServices(coreContext *core.Context) error {
+	services := make([]core.IService, 0, len(w.services)+len(w.runners))
+	services = append(services, w.services...)
+	for _, name := range slices.Sorted(maps.Keys(w.namedServices)) {
+		services = append(services, w.namedServices[name])
+	}
+	for _, runner := range w.runners {
+		services = append(services, runner)
+	}
+	order, err := core.DependencyOrder(services)
+	if err != nil {
+		log.Error("Failed to sort the services", zap.Error(err))
+		return err
+	}
+	for _, i := range order {
+		err := core.Inject(services[i], coreContext)
+		if err != nil {
+			log.Error("Failed to inject the service", zap.Error(err))
+			return err
+		}
+		err = services[i].Init(coreContext)
+		if err != nil {
+			return errors.WithStackIf(err)
+		}
+	}
+	for _, component := range w.component {
+		w.lifecycle = append(w.lifecycle, component)
+	}
+	runnerStart := len(services) - len(w.runners)
+	runners := make([]core.IRunner, 0, len(w.runners))
+	w.lifecycleRunners = make(map[int]int)
+	for _, i := range order {
+		if i >= runnerStart {
+			w.lifecycleRunners[len(w.lifecycle)] = len(runners)
+			runners = append(runners, w.runners[i-runnerStart])
+		}
+		w.lifecycle = append(w.lifecycle, services[i])
+	}
+	w.runners = runners
 	return nil
 }
 
-func (w *WebFrame) Daemon(svcConfig *service.Config) {
-	RunDaemon(w, svcConfig)
+// addHealthChecks 数据库、定时任务、runner 以及实现了 IHealthCheck 的对象加入健康检查，
+// runner 的 Run 返回错误时存活检查失败
+func (w *WebFrame) addHealthChecks() {
+	modelGroups := append([]core.IModelGroup{w.defaultModelGroup}, w.modelGroup...)
+	for _, modelGroup := range modelGroups {
+		if check, ok := modelGroup.(core.IHealthCheck); ok {
+			name := "db"
+			if modelGroup != w.defaultModelGroup {
+				name = "db:" + modelGroup.Name()
+			}
+			w.health.AddCheck(name, check.HealthCheck)
+		}
+	}
+	w.health.AddCheck("schedule", w.schedule.HealthCheck)
+	for _, model := range w.models {
+		w.health.Add(model)
+	}
+	for i, item := range w.lifecycle {
+		if index, ok := w.lifecycleRunners[i]; ok {
+			w.health.AddLivenessCheck("runner:"+util.GetStructFullName(item), w.server.RunnerHealthCheck(index))
+			continue
+		}
+		w.health.Add(item)
+	}
+}
+
+// Ready 返回的通道在所有 http 服务端口绑定成功且 OnStarted 执行完成后关闭
+func (w *WebFrame) Ready() <-chan struct{} {
+	return w.ready
+}
+
+// Daemon 以系统服务运行或管理服务，args 未指定时使用 os.Args[1:]，web.daemon 配置覆盖 svcConfig，返回退出码
+func (w *WebFrame) Daemon(svcConfig *service.Config, args ...string) int {
+	var daemonConfig DaemonConfig
+	err := w.config.Unmarshal(daemonConfig.Key(), &daemonConfig)
+	if err != nil {
+		log.Errors("Invalid daemon config", err)
+		return ExitFailure
+	}
+	if args == nil {
+		args = os.Args[1:]
+	}
+	return RunDaemon(w, daemonConfig.Apply(svcConfig), args...)
 }
 
 func (w *WebFrame) Authentication(authentication web.Authentication) {
 	w.authentication = authentication
 }
+
+func (w *WebFrame) GetAuthentication() web.Authentication {
+	return w.authentication
+}
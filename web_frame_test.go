@@ -0,0 +1,235 @@
+package wf
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	config2 "github.com/chuccp/go-web-frame/config"
+	"github.com/chuccp/go-web-frame/core"
+	"github.com/chuccp/go-web-frame/web"
+)
+
+type lifecycleComponent struct {
+	events []string
+}
+
+func (c *lifecycleComponent) Init(config config2.IConfig) error {
+	c.events = append(c.events, "init")
+	return nil
+}
+func (c *lifecycleComponent) Destroy() error {
+	c.events = append(c.events, "destroy")
+	return nil
+}
+func (c *lifecycleComponent) OnStarted(ctx context.Context) error {
+	c.events = append(c.events, "started")
+	return nil
+}
+func (c *lifecycleComponent) OnStopping(ctx context.Context) error {
+	c.events = append(c.events, "stopping")
+	return nil
+}
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestLifecycle(t *testing.T) {
+	config := config2.NewConfig()
+	config.Put("web.server.port", freePort(t))
+	webFrame := New(config)
+	component := &lifecycleComponent{}
+	webFrame.AddComponent(component)
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- webFrame.Start()
+	}()
+	select {
+	case <-webFrame.Ready():
+	case err := <-startErr:
+		t.Fatal(err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the service is not ready")
+	}
+	err := webFrame.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-startErr; err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"init", "started", "stopping", "destroy"}
+	if len(component.events) != len(expected) {
+		t.Fatalf("unexpected events %v", component.events)
+	}
+	for i, event := range expected {
+		if component.events[i] != event {
+			t.Fatalf("unexpected events %v", component.events)
+		}
+	}
+}
+
+func TestListenFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	config := config2.NewConfig()
+	config.Put("web.server.host", "127.0.0.1")
+	config.Put("web.server.port", listener.Addr().(*net.TCPAddr).Port)
+	webFrame := New(config)
+	component := &lifecycleComponent{}
+	webFrame.AddComponent(component)
+	if err := webFrame.Start(); err == nil {
+		t.Fatal("expected a listen error")
+	}
+	if len(component.events) == 0 || component.events[len(component.events)-1] != "destroy" {
+		t.Fatalf("components should be destroyed after the listen error, got %v", component.events)
+	}
+}
+
+type unhealthyComponent struct {
+}
+
+func (c *unhealthyComponent) Init(config config2.IConfig) error {
+	return nil
+}
+func (c *unhealthyComponent) Destroy() error {
+	return nil
+}
+func (c *unhealthyComponent) HealthCheck(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestHealth(t *testing.T) {
+	port := freePort(t)
+	config := config2.NewConfig()
+	config.Put("web.server.port", port)
+	config.Put("web.health.enable", true)
+	webFrame := New(config)
+	webFrame.AddComponent(&unhealthyComponent{})
+	go func() {
+		_ = webFrame.Start()
+	}()
+	<-webFrame.Ready()
+	defer webFrame.Close()
+	get := func(path string) (int, *web.Message) {
+		response, err := http.Get("http://127.0.0.1:" + strconv.Itoa(port) + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		var message web.Message
+		err = json.NewDecoder(response.Body).Decode(&message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response.StatusCode, &message
+	}
+	if code, _ := get("/healthz"); code != http.StatusOK {
+		t.Fatalf("liveness status %d", code)
+	}
+	code, message := get("/readyz")
+	if code != http.StatusServiceUnavailable || message.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readiness status %d", code)
+	}
+	checks := message.Data.(map[string]any)["checks"].(map[string]any)
+	check := checks["wf.unhealthyComponent"].(map[string]any)
+	if check["status"] != "down" || check["error"] != "connection refused" {
+		t.Fatalf("unexpected check %v", check)
+	}
+}
+
+// failedRunner 函数类型不可比较，runner 不能作为 map 的键或用 == 比较
+type failedRunner func() error
+
+func (r failedRunner) Init(ctx *core.Context) error {
+	return nil
+}
+func (r failedRunner) Run() error {
+	return r()
+}
+func (r failedRunner) Destroy() error {
+	return nil
+}
+
+func TestRunnerHealth(t *testing.T) {
+	port := freePort(t)
+	config := config2.NewConfig()
+	config.Put("web.server.port", port)
+	config.Put("web.health.enable", true)
+	webFrame := New(config)
+	webFrame.AddRunner(failedRunner(func() error { return errors.New("stopped") }))
+	go func() {
+		_ = webFrame.Start()
+	}()
+	<-webFrame.Ready()
+	defer webFrame.Close()
+	for i := 0; ; i++ {
+		response, err := http.Get("http://127.0.0.1:" + strconv.Itoa(port) + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode == http.StatusServiceUnavailable {
+			return
+		}
+		if i == 50 {
+			t.Fatalf("liveness status %d", response.StatusCode)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestEnvOnlyConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("WEB_SERVER_PORT", "18181")
+	t.Setenv("WEB_SERVER_SSL_ENABLED", "true")
+	t.Setenv("WEB_DB_TYPE", "sqlite")
+	config := LoadAutoConfig()
+	defer config.Close()
+	var serverConfig web.ServerConfig
+	if err := config.Unmarshal(web.ServerConfigKey, &serverConfig); err != nil {
+		t.Fatal(err)
+	}
+	if serverConfig.Port != 18181 || !serverConfig.SSL.Enabled {
+		t.Fatalf("environment variables were not applied: %+v", serverConfig)
+	}
+	if config.GetString("web.db.type") != "sqlite" {
+		t.Fatalf("unexpected db type %q", config.GetString("web.db.type"))
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	config := config2.NewConfig()
+	config.Put("web.server.port", 70000)
+	config.Put("web.log.level", "verbose")
+	config.Put("web.schedule.enabled", true)
+	w := New(config)
+	err := w.Start()
+	if err == nil {
+		t.Fatal("expected invalid config error")
+	}
+	for _, message := range []string{
+		"web.log: unrecognized level",
+		"web.schedule.enabled: unknown key",
+		"web.server.port: must be 1-65535",
+	} {
+		if !strings.Contains(err.Error(), message) {
+			t.Errorf("expected %q in %q", message, err.Error())
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package wftest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/chuccp/go-web-frame/web"
+	"github.com/gin-gonic/gin"
+)
+
+// Request 构造测试请求，调用 Do 或 Expect 系列方法时发送
+type Request struct {
+	app     *App
+	method  string
+	path    string
+	query   url.Values
+	header  http.Header
+	cookies []*http.Cookie
+	body    io.Reader
+	user    any
+	hasUser bool
+}
+
+func newRequest(app *App, method string, path string) *Request {
+	return &Request{
+		app:    app,
+		method: method,
+		path:   path,
+		query:  url.Values{},
+		header: http.Header{},
+	}
+}
+
+func (r *Request) WithHeader(key string, value string) *Request {
+	r.header.Add(key, value)
+	return r
+}
+
+func (r *Request) WithCookie(cookie *http.Cookie) *Request {
+	r.cookies = append(r.cookies, cookie)
+	return r
+}
+
+func (r *Request) WithQuery(key string, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// WithJSON 请求体编码为 JSON
+func (r *Request) WithJSON(body any) *Request {
+	r.app.t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		r.app.t.Fatalf("wftest: marshal body: %v", err)
+	}
+	r.body = bytes.NewReader(data)
+	r.header.Set("Content-Type", "application/json")
+	return r
+}
+
+// WithForm 请求体编码为表单
+func (r *Request) WithForm(values url.Values) *Request {
+	r.body = strings.NewReader(values.Encode())
+	r.header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func (r *Request) WithBody(contentType string, body io.Reader) *Request {
+	r.body = body
+	r.header.Set("Content-Type", contentType)
+	return r
+}
+
+// WithUser 发送前调用 Authentication.SignIn 登录，并把 SignIn 写入的 cookie 和响应头带到请求中
+func (r *Request) WithUser(user any) *Request {
+	r.user = user
+	r.hasUser = true
+	return r
+}
+
+func (r *Request) build() *http.Request {
+	r.app.t.Helper()
+	target := r.path
+	if len(r.query) > 0 {
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		target += separator + r.query.Encode()
+	}
+	request := httptest.NewRequest(r.method, target, r.body)
+	for key, values := range r.header {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+	for _, cookie := range r.cookies {
+		request.AddCookie(cookie)
+	}
+	if r.hasUser {
+		r.signIn(request)
+	}
+	return request
+}
+
+func (r *Request) signIn(request *http.Request) {
+	r.app.t.Helper()
+	authentication := r.app.frame.GetAuthentication()
+	if authentication == nil {
+		r.app.t.Fatal("wftest: WithUser requires WebFrame.Authentication")
+	}
+	recorder := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	ginContext.Request = request.Clone(request.Context())
+	_, err := authentication.SignIn(r.user, web.NewRequest(ginContext, web.NewDigestAuth(authentication)))
+	if err != nil {
+		r.app.t.Fatalf("wftest: sign in: %+v", err)
+	}
+	response := recorder.Result()
+	for _, cookie := range response.Cookies() {
+		request.AddCookie(cookie)
+	}
+	for key, values := range response.Header {
+		if key == "Set-Cookie" || key == "Content-Type" || key == "Content-Length" {
+			continue
+		}
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+}
+
+// Do 发送请求并返回响应
+func (r *Request) Do() *Response {
+	r.app.t.Helper()
+	recorder := httptest.NewRecorder()
+	r.app.handler.ServeHTTP(recorder, r.build())
+	return &Response{t: r.app.t, recorder: recorder}
+}
+
+// Expect 发送请求并检查状态码
+func (r *Request) Expect(status int) *Response {
+	r.app.t.Helper()
+	return r.Do().Expect(status)
+}
+
+// ExpectJSON 发送请求，检查状态码以及 web.Message 中的 code，指定 data 时检查 Message.Data
+func (r *Request) ExpectJSON(status int, data ...any) *Response {
+	r.app.t.Helper()
+	return r.Do().ExpectJSON(status, data...)
+}
+
+// ExpectMessage 发送请求，检查状态码以及 web.Message 中的 code 和 msg
+func (r *Request) ExpectMessage(status int, msg string) *Response {
+	r.app.t.Helper()
+	return r.Do().ExpectMessage(status, msg)
+}
@@ -0,0 +1,106 @@
+package wftest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/chuccp/go-web-frame/web"
+)
+
+type Response struct {
+	t        testing.TB
+	recorder *httptest.ResponseRecorder
+}
+
+func (r *Response) Status() int {
+	return r.recorder.Code
+}
+
+func (r *Response) Header() http.Header {
+	return r.recorder.Header()
+}
+
+func (r *Response) Cookies() []*http.Cookie {
+	return r.recorder.Result().Cookies()
+}
+
+func (r *Response) Body() string {
+	return r.recorder.Body.String()
+}
+
+// Message 响应体解析为 web.Message
+func (r *Response) Message() *web.Message {
+	r.t.Helper()
+	var message web.Message
+	if err := json.Unmarshal(r.recorder.Body.Bytes(), &message); err != nil {
+		r.t.Fatalf("wftest: response is not a web.Message: %v, body: %s", err, r.Body())
+	}
+	return &message
+}
+
+// Decode 将 web.Message 中的 Data 解析到 v
+func (r *Response) Decode(v any) *Response {
+	r.t.Helper()
+	var message struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(r.recorder.Body.Bytes(), &message); err != nil {
+		r.t.Fatalf("wftest: response is not a web.Message: %v, body: %s", err, r.Body())
+	}
+	if err := json.Unmarshal(message.Data, v); err != nil {
+		r.t.Fatalf("wftest: decode data: %v, data: %s", err, message.Data)
+	}
+	return r
+}
+
+func (r *Response) Expect(status int) *Response {
+	r.t.Helper()
+	if r.Status() != status {
+		r.t.Fatalf("wftest: expected status %d, got %d, body: %s", status, r.Status(), r.Body())
+	}
+	return r
+}
+
+// ExpectJSON 检查状态码以及 web.Message 中的 code，指定 data 时按 JSON 比较 Message.Data
+func (r *Response) ExpectJSON(status int, data ...any) *Response {
+	r.t.Helper()
+	r.Expect(status)
+	message := r.Message()
+	if message.Code != status {
+		r.t.Fatalf("wftest: expected message code %d, got %d, body: %s", status, message.Code, r.Body())
+	}
+	if len(data) > 0 {
+		expected, actual := normalize(r.t, data[0]), normalize(r.t, message.Data)
+		if !reflect.DeepEqual(expected, actual) {
+			r.t.Fatalf("wftest: expected data %v, got %v", expected, actual)
+		}
+	}
+	return r
+}
+
+// ExpectMessage 检查状态码以及 web.Message 中的 code 和 msg
+func (r *Response) ExpectMessage(status int, msg string) *Response {
+	r.t.Helper()
+	r.ExpectJSON(status)
+	if message := r.Message(); message.Msg != msg {
+		r.t.Fatalf("wftest: expected msg %q, got %q", msg, message.Msg)
+	}
+	return r
+}
+
+// normalize 经过一次 JSON 编解码，使结构体与解析出的 map 可以比较
+func normalize(t testing.TB, v any) any {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("wftest: marshal: %v", err)
+	}
+	var n any
+	if err := json.Unmarshal(data, &n); err != nil {
+		t.Fatalf("wftest: unmarshal: %v", err)
+	}
+	return n
+}
@@ -0,0 +1,104 @@
+// Package wftest 在测试中启动完整的 WebFrame 应用，不绑定端口，请求通过 httptest 直接交给 gin 处理
+//
+//	app := wftest.New(t, nil, func(w *wf.WebFrame) {
+//		w.AddRest(&UserRest{})
+//		w.Authentication(&Authentication{})
+//	})
+//	app.GET("/user/info").WithUser(&User{Id: 1}).ExpectJSON(200, map[string]any{"id": 1})
+package wftest
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	wf "github.com/chuccp/go-web-frame"
+	config2 "github.com/chuccp/go-web-frame/config"
+	"github.com/chuccp/go-web-frame/core"
+	db2 "github.com/chuccp/go-web-frame/db"
+)
+
+// App 测试中的应用，测试结束时自动关闭
+type App struct {
+	t       testing.TB
+	frame   *wf.WebFrame
+	config  *config2.Config
+	handler http.Handler
+}
+
+// New 使用内存中的配置创建并初始化应用，config 为 nil 时使用空配置，
+// 未配置 web.db 时使用临时目录中的 SQLite 数据库，runner 不会运行
+func New(t testing.TB, config *config2.Config, setup ...func(w *wf.WebFrame)) *App {
+	t.Helper()
+	if config == nil {
+		config = config2.NewConfig()
+	}
+	if !config.HasKey(db2.ConfigKey) {
+		config.Put(db2.ConfigKey+".type", db2.SQLITE)
+		config.Put(db2.ConfigKey+".filePath", filepath.Join(t.TempDir(), "wftest.db"))
+	}
+	frame := wf.New(config)
+	for _, f := range setup {
+		f(frame)
+	}
+	err := frame.Prepare()
+	if err != nil {
+		t.Fatalf("wftest: prepare app: %+v", err)
+	}
+	t.Cleanup(func() {
+		if err := frame.Close(); err != nil {
+			t.Errorf("wftest: close app: %+v", err)
+		}
+	})
+	app := &App{t: t, frame: frame, config: config}
+	app.handler = app.mustHandler()
+	return app
+}
+
+func (a *App) mustHandler(port ...int) http.Handler {
+	a.t.Helper()
+	handler := a.frame.Handler(port...)
+	if handler == nil {
+		a.t.Fatalf("wftest: no http server on port %v", port)
+	}
+	return handler
+}
+
+// Port 返回请求发送到指定端口的 App，用于测试 GetRestGroup 创建的其他端口
+func (a *App) Port(port int) *App {
+	a.t.Helper()
+	return &App{t: a.t, frame: a.frame, config: a.config, handler: a.mustHandler(port)}
+}
+
+func (a *App) Frame() *wf.WebFrame {
+	return a.frame
+}
+
+func (a *App) Config() *config2.Config {
+	return a.config
+}
+
+// Context 用于在测试中获取 service、model 和组件
+func (a *App) Context() *core.Context {
+	return a.frame.Context()
+}
+
+func (a *App) Request(method string, path string) *Request {
+	return newRequest(a, method, path)
+}
+
+func (a *App) GET(path string) *Request {
+	return a.Request(http.MethodGet, path)
+}
+
+func (a *App) POST(path string) *Request {
+	return a.Request(http.MethodPost, path)
+}
+
+func (a *App) PUT(path string) *Request {
+	return a.Request(http.MethodPut, path)
+}
+
+func (a *App) DELETE(path string) *Request {
+	return a.Request(http.MethodDelete, path)
+}
@@ -0,0 +1,74 @@
+package wftest
+
+import (
+	"net/http"
+	"testing"
+
+	wf "github.com/chuccp/go-web-frame"
+	"github.com/chuccp/go-web-frame/core"
+	"github.com/chuccp/go-web-frame/web"
+)
+
+type user struct {
+	Name string `json:"name"`
+}
+
+type cookieAuthentication struct {
+}
+
+func (a *cookieAuthentication) SignIn(u any, request *web.Request) (any, error) {
+	request.Cookie().Set("user", u.(*user).Name)
+	return u, nil
+}
+
+func (a *cookieAuthentication) SignOut(request *web.Request) (any, error) {
+	request.Cookie().Delete("user")
+	return nil, nil
+}
+
+func (a *cookieAuthentication) User(request *web.Request) (any, error) {
+	name := request.Cookie().Get("user")
+	if len(name) == 0 {
+		return nil, web.NoLogin
+	}
+	return &user{Name: name}, nil
+}
+
+func (a *cookieAuthentication) NewUser() any {
+	return &user{}
+}
+
+type userRest struct {
+}
+
+func (r *userRest) Init(ctx *core.Context) error {
+	ctx.GetAuth("/user", func(req *web.Request) (any, error) {
+		return req.User()
+	})
+	ctx.Post("/echo", func(req *web.Request) (any, error) {
+		var body map[string]any
+		if err := req.BindJSON(&body); err != nil {
+			return nil, err
+		}
+		return body, nil
+	})
+	return nil
+}
+
+func TestApp(t *testing.T) {
+	app := New(t, nil, func(w *wf.WebFrame) {
+		w.AddRest(&userRest{})
+		w.Authentication(&cookieAuthentication{})
+	})
+	app.GET("/user").ExpectJSON(http.StatusUnauthorized)
+	app.GET("/user").WithUser(&user{Name: "alice"}).ExpectJSON(http.StatusOK, &user{Name: "alice"})
+
+	var echo map[string]int
+	app.POST("/echo").WithJSON(map[string]int{"a": 1}).ExpectJSON(http.StatusOK).Decode(&echo)
+	if echo["a"] != 1 {
+		t.Fatalf("unexpected echo %v", echo)
+	}
+	if app.Context().GetConfig().GetString("web.db.type") != "sqlite" {
+		t.Fatal("a temporary sqlite db should be configured")
+	}
+}